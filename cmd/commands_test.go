@@ -0,0 +1,349 @@
+package cmd
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/lorislab/samo/pkg/samo"
+	"github.com/lorislab/samo/tools"
+	"github.com/spf13/viper"
+)
+
+var update = flag.Bool("update", false, "update the golden files of the command sequences")
+
+// testGit git backend with the fixed describe of the HEAD and of the previous tag
+type testGit struct {
+	describe, previous samo.Describe
+	branch             string
+}
+
+func (g testGit) Describe(_ context.Context) (samo.Describe, error) {
+	return g.describe, nil
+}
+
+func (g testGit) DescribeExclude(_ context.Context, _ string) (samo.Describe, error) {
+	return g.previous, nil
+}
+
+func (g testGit) Branch(_ context.Context) (string, error) {
+	return g.branch, nil
+}
+
+func (g testGit) LogMessages(_ context.Context, _, _ string) ([]string, error) {
+	return nil, nil
+}
+
+func (g testGit) Source(_ context.Context) (string, error) {
+	return "https://github.com/lorislab/samo-test.git", nil
+}
+
+// testProject project of the git backend, the version is calculated with the default flags
+func testProject(t *testing.T, ctx context.Context, git testGit) *Project {
+	t.Helper()
+	flags := projectFlags{}
+	readTestOptions(t, &flags, nil)
+	project, err := samo.New(ctx, samo.Options{
+		FirstVersion:    flags.FirstVersion,
+		VersionTemplate: flags.VersionTemplate,
+		BranchTemplate:  flags.BranchTemplate,
+		Strategy:        samo.DefaultStrategy{},
+	}, git)
+	if err != nil {
+		t.Fatalf("create project: %v", err)
+	}
+	return project
+}
+
+// readTestOptions read the flags with the default values and the overrides, the overrides are removed after the test
+func readTestOptions(t *testing.T, options interface{}, overrides map[string]interface{}) {
+	t.Helper()
+	for key, value := range overrides {
+		viper.Set(key, value)
+		t.Cleanup(func() { viper.Set(key, nil) })
+	}
+	if err := readOptions(options); err != nil {
+		t.Fatalf("read options: %v", err)
+	}
+}
+
+// assertGolden compare the command lines with the golden file testdata/<name>.golden
+func assertGolden(t *testing.T, name string, lines []string) {
+	t.Helper()
+	file := filepath.Join("testdata", name+".golden")
+	actual := strings.Join(lines, "\n") + "\n"
+	if *update {
+		if err := os.WriteFile(file, []byte(actual), 0644); err != nil {
+			t.Fatalf("write golden file %s: %v", file, err)
+		}
+		return
+	}
+	expected, err := os.ReadFile(file)
+	if err != nil {
+		t.Fatalf("read golden file %s: %v", file, err)
+	}
+	if actual != string(expected) {
+		t.Errorf("command sequence of %s\n--- expected\n%s--- actual\n%s", name, expected, actual)
+	}
+}
+
+// testChdir change the working directory to the temporary directory, the golden files are read before
+func testChdir(t *testing.T) string {
+	t.Helper()
+	golden, err := filepath.Abs("testdata")
+	if err != nil {
+		t.Fatal(err)
+	}
+	dir := t.TempDir()
+	t.Chdir(dir)
+	if err := os.Symlink(golden, "testdata"); err != nil {
+		t.Fatal(err)
+	}
+	return dir
+}
+
+func writeTestFile(t *testing.T, name, content string) {
+	t.Helper()
+	if err := os.MkdirAll(filepath.Dir(name), 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(name, []byte(content), 0644); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestDockerBuild(t *testing.T) {
+	testChdir(t)
+	writeTestFile(t, "src/main/docker/Dockerfile", "FROM scratch\n")
+
+	fake := samo.NewFakeRunner()
+	ctx := samo.WithRunner(context.Background(), fake)
+	project := testProject(t, ctx, testGit{
+		describe: samo.Describe{Tag: "1.0.0", Count: "2", Hash: "abc123"},
+		branch:   "main",
+	})
+
+	flags := dockerBuildFlags{}
+	readTestOptions(t, &flags, map[string]interface{}{
+		"docker-registry":                   "registry.example.com",
+		"docker-group":                      "lorislab",
+		"docker-build-push":                 true,
+		"skip-samo-labels":                  true,
+		"docker-skip-opencontainers-labels": true,
+		"labels-template-list":              "branch={{ .Branch }}",
+	})
+	if err := dockerBuild(ctx, project, flags); err != nil {
+		t.Fatalf("docker build: %v", err)
+	}
+	assertGolden(t, "docker_build", fake.Lines())
+}
+
+func TestDockerRelease(t *testing.T) {
+	tests := []struct {
+		name      string
+		overrides map[string]interface{}
+	}{
+		{name: "docker_release", overrides: map[string]interface{}{
+			"docker-registry":         "registry.example.com",
+			"docker-group":            "lorislab",
+			"docker-release-registry": "release.example.com",
+		}},
+		{name: "docker_release_image_tools", overrides: map[string]interface{}{
+			"docker-registry":            "registry.example.com",
+			"docker-group":               "lorislab",
+			"docker-release-registry":    "release.example.com",
+			"docker-release-image-tools": true,
+		}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			fake := samo.NewFakeRunner()
+			ctx := samo.WithRunner(context.Background(), fake)
+			project := testProject(t, ctx, testGit{
+				describe: samo.Describe{Tag: "1.1.0", Count: "0", Hash: "abc123"},
+				previous: samo.Describe{Tag: "1.0.0", Count: "3", Hash: "abc123"},
+				branch:   "main",
+			})
+
+			flags := dockerReleaseFlags{}
+			readTestOptions(t, &flags, tt.overrides)
+			if err := dockerRelease(ctx, project, flags); err != nil {
+				t.Fatalf("docker release: %v", err)
+			}
+			assertGolden(t, tt.name, fake.Lines())
+		})
+	}
+}
+
+func TestDockerReleaseNoTag(t *testing.T) {
+	fake := samo.NewFakeRunner()
+	ctx := samo.WithRunner(context.Background(), fake)
+	project := testProject(t, ctx, testGit{
+		describe: samo.Describe{Tag: "1.0.0", Count: "2", Hash: "abc123"},
+		branch:   "main",
+	})
+
+	flags := dockerReleaseFlags{}
+	readTestOptions(t, &flags, nil)
+	if err := dockerRelease(ctx, project, flags); err == nil {
+		t.Fatal("docker release without the tag at HEAD must fail")
+	}
+	if lines := fake.Lines(); len(lines) > 0 {
+		t.Errorf("docker release without the tag executed the commands %v", lines)
+	}
+}
+
+func TestHelmBuild(t *testing.T) {
+	testChdir(t)
+	writeTestFile(t, "src/main/helm/Chart.yaml", "apiVersion: v2\nname: samo-test\nversion: 0.0.0\nappVersion: 0.0.0\n")
+	writeTestFile(t, "src/main/helm/values.yaml", "image:\n  tag: latest\n")
+	writeTestFile(t, "src/main/helm/templates/configmap.yaml", "apiVersion: v1\nkind: ConfigMap\nmetadata:\n  name: {{ .Chart.Name }}\ndata:\n  tag: {{ .Values.image.tag | quote }}\n")
+
+	fake := samo.NewFakeRunner()
+	ctx := samo.WithRunner(context.Background(), fake)
+	project := testProject(t, ctx, testGit{
+		describe: samo.Describe{Tag: "1.0.0", Count: "2", Hash: "abc123"},
+		branch:   "main",
+	})
+
+	flags := helmBuildFlags{}
+	readTestOptions(t, &flags, map[string]interface{}{
+		"helm-source-dir":           "src/main/helm",
+		"helm-source-copy":          true,
+		"helm-clean":                true,
+		"helm-repo":                 "lorislab",
+		"helm-repo-url":             "https://lorislab.github.io/helm",
+		"helm-repo-username":        "user",
+		"helm-repo-password":        "secret",
+		"helm-values-template-list": "image.tag={{ .Version }}",
+		"helm-lint":                 true,
+		"skip-samo-labels":          true,
+	})
+	if err := helmBuild(ctx, project, flags); err != nil {
+		t.Fatalf("helm build: %v", err)
+	}
+	assertGolden(t, "helm_build", fake.Lines())
+
+	values, err := os.ReadFile("target/helm/samo-test/values.yaml")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(string(values), "tag: 1.1.0-rc.2") {
+		t.Errorf("values.yaml has not the image tag of the version:\n%s", values)
+	}
+}
+
+func TestRelease(t *testing.T) {
+	tests := []struct {
+		name     string
+		golden   string
+		remote   bool
+		noRemote bool
+	}{
+		{name: "offline", golden: "release"},
+		{name: "remote checks", golden: "release_remote", remote: true},
+		{name: "remote checks without origin", golden: "release_no_origin", remote: true, noRemote: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			fake := samo.NewFakeRunner()
+			if tt.noRemote {
+				fake.On("git remote get-url origin", "", errors.New("exit status 2"))
+			}
+			fake.On("git symbolic-ref -q HEAD", "refs/heads/main", nil).
+				On("git rev-parse --abbrev-ref --symbolic-full-name @{u}", "origin/main", nil).
+				On("git rev-list --left-right --count", "0\t0", nil).
+				On("git rev-parse -q --verify refs/tags/", "", errors.New("exit status 1")).
+				On("git tag --list", "0.9.0\n1.0.0", nil)
+			ctx := samo.WithRunner(context.Background(), fake)
+			project := testProject(t, ctx, testGit{
+				describe: samo.Describe{Tag: "1.0.0", Count: "2", Hash: "abc123"},
+				branch:   "main",
+			})
+
+			flags := projectReleaseFlags{}
+			readTestOptions(t, &flags, map[string]interface{}{
+				"release-branches":     "^main$",
+				"release-check-remote": tt.remote,
+			})
+			if err := release(ctx, project, flags); err != nil {
+				t.Fatalf("release: %v", err)
+			}
+			assertGolden(t, tt.golden, fake.Lines())
+		})
+	}
+}
+
+func TestReleaseCheckFailed(t *testing.T) {
+	fake := samo.NewFakeRunner().
+		On("git status --porcelain --untracked-files=no", " M README.md", nil).
+		On("git rev-parse -q --verify refs/tags/", "", errors.New("exit status 1"))
+	ctx := samo.WithRunner(context.Background(), fake)
+	project := testProject(t, ctx, testGit{
+		describe: samo.Describe{Tag: "1.0.0", Count: "2", Hash: "abc123"},
+		branch:   "main",
+	})
+
+	flags := projectReleaseFlags{}
+	readTestOptions(t, &flags, map[string]interface{}{
+		"skip-push": true,
+	})
+	if err := release(ctx, project, flags); err == nil {
+		t.Fatal("release of the changed working tree must fail")
+	}
+	for _, line := range fake.Lines() {
+		if strings.HasPrefix(line, "git tag -a") {
+			t.Errorf("release with the failed checks created the tag: %s", line)
+		}
+	}
+}
+
+func TestReleaseMergeBack(t *testing.T) {
+	tests := []struct {
+		name   string
+		counts string
+		golden string
+		fail   bool
+	}{
+		{name: "up to date", counts: "0\t0", golden: "release_merge_back"},
+		{name: "behind", counts: "0\t3", golden: "release_merge_back_behind"},
+		{name: "ahead", counts: "1\t0", fail: true},
+		{name: "diverged", counts: "1\t3", fail: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			fake := samo.NewFakeRunner().
+				On("git rev-list --left-right --count", tt.counts, nil)
+			ctx := samo.WithRunner(context.Background(), fake)
+			project := testProject(t, ctx, testGit{
+				describe: samo.Describe{Tag: "1.3.0-rc.1", Count: "2", Hash: "abc123"},
+				branch:   "release/1.3",
+			})
+
+			flags := projectReleaseFlags{}
+			readTestOptions(t, &flags, map[string]interface{}{
+				"release-merge-branch": "main",
+			})
+			err := releaseMergeBack(ctx, project, "1.3.0", flags)
+			if tt.fail {
+				if !errors.Is(err, tools.ErrPrecondition) {
+					t.Fatalf("merge back error %v, expected precondition error", err)
+				}
+				for _, line := range fake.Lines() {
+					if strings.HasPrefix(line, "git merge") || strings.HasPrefix(line, "git push") {
+						t.Errorf("merge back of the not up to date branch called %s", line)
+					}
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("merge back: %v", err)
+			}
+			assertGolden(t, tt.golden, fake.Lines())
+		})
+	}
+}
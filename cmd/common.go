@@ -1,7 +1,10 @@
 package cmd
 
 import (
+	"fmt"
+
 	"github.com/lorislab/samo/log"
+	"github.com/lorislab/samo/tools"
 	"gopkg.in/yaml.v2"
 
 	"github.com/spf13/cobra"
@@ -9,14 +12,14 @@ import (
 	"github.com/spf13/viper"
 )
 
-func readOptions(options interface{}) interface{} {
+func readOptions(options interface{}) error {
 	err := viper.Unmarshal(options)
 	if err != nil {
-		log.Fatal("error unmarshal options", log.E(err))
+		return fmt.Errorf("%w: error unmarshal options: %v", tools.ErrInvalidInput, err)
 	}
 	d, _ := yaml.Marshal(options)
 	log.Debug("Configuration:\n" + string(d))
-	return options
+	return nil
 }
 
 func addChildCmd(parent, child *cobra.Command) {
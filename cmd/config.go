@@ -0,0 +1,587 @@
+package cmd
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/lorislab/samo/log"
+	"github.com/lorislab/samo/tools"
+	"github.com/santhosh-tekuri/jsonschema/v6"
+	"github.com/santhosh-tekuri/jsonschema/v6/kind"
+	"github.com/spf13/cobra"
+	"github.com/spf13/pflag"
+	"github.com/spf13/viper"
+	"golang.org/x/text/language"
+	"golang.org/x/text/message"
+	"gopkg.in/yaml.v3"
+)
+
+type configShowFlags struct {
+	Format string `mapstructure:"config-format"`
+}
+
+type configInitFlags struct {
+	Force bool `mapstructure:"config-force"`
+}
+
+// configFlag flag of the configuration file
+type configFlag struct {
+	Flag *pflag.Flag
+	// Command path of the first command which defines the flag
+	Command string
+}
+
+// configValue effective value of the configuration key
+type configValue struct {
+	Key    string      `json:"key"`
+	Value  interface{} `json:"value"`
+	Source string      `json:"source"`
+}
+
+// configErrorsAnnotation annotation of the command which runs with the invalid configuration files
+const configErrorsAnnotation = "samo-config-errors"
+
+// configIgnoredFlags flags which are not the keys of the configuration file
+var configIgnoredFlags = map[string]bool{"help": true, "config": true, "verbosity": true}
+
+func createConfigCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:              "config",
+		Short:            "Configuration operation",
+		Long:             `Validate, show and create the samo configuration file.`,
+		TraverseChildren: true,
+	}
+	addChildCmd(cmd, createConfigValidateCmd())
+	addChildCmd(cmd, createConfigShowCmd())
+	addChildCmd(cmd, createConfigInitCmd())
+	return cmd
+}
+
+func createConfigValidateCmd() *cobra.Command {
+	return &cobra.Command{
+		Use:   "validate [file]",
+		Short: "Validate the configuration file",
+		Long: `Validate the configuration file against the JSON schema of the samo flags and pipelines.
+The unknown keys and the type errors are reported with the line numbers.
+Default files are all layers of the configuration with the included files.`,
+		Args: cobra.MaximumNArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			files := configLayerFiles(cfgFile)
+			if len(args) > 0 {
+				files = []string{args[0]}
+			}
+			return configValidate(os.Stdout, files)
+		},
+		// the validation reports the errors of the configuration files
+		Annotations:      map[string]string{configErrorsAnnotation: "ignore"},
+		TraverseChildren: true,
+	}
+}
+
+func createConfigShowCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "show",
+		Short: "Show the effective configuration",
+		Long: `Show the effective configuration of the flags, SAMO_* environment variables, configuration file and defaults
+with the source of each value: flag, env, file or default. All samo flags are accepted.
+The secret values are redacted.`,
+		Args: cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			flags := configShowFlags{}
+			if err := readOptions(&flags); err != nil {
+				return err
+			}
+			return configShow(os.Stdout, flags)
+		},
+		TraverseChildren: true,
+	}
+	addStringFlag(cmd, "config-format", "", "table", "output format of the configuration. Values: table,json")
+
+	// share the flags of all commands, the values of the flags are the part of the configuration.
+	// The required flags of the commands are not shared, they are not required by the show command.
+	for _, f := range configFlagDefinitions() {
+		if cmd.Flags().Lookup(f.Flag.Name) != nil || rootCmd.PersistentFlags().Lookup(f.Flag.Name) != nil {
+			continue
+		}
+		if _, required := f.Flag.Annotations[cobra.BashCompOneRequiredFlag]; required {
+			continue
+		}
+		if len(f.Flag.Shorthand) > 0 && cmd.Flags().ShorthandLookup(f.Flag.Shorthand) != nil {
+			continue
+		}
+		cmd.Flags().AddFlag(f.Flag)
+	}
+	return cmd
+}
+
+func createConfigInitCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "init [file]",
+		Short: "Create the starter configuration file",
+		Long: `Create the commented starter configuration file from the flag definitions. Default file is .samo.yaml,
+use - to print the file.`,
+		Args: cobra.MaximumNArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			flags := configInitFlags{}
+			if err := readOptions(&flags); err != nil {
+				return err
+			}
+			file := ".samo.yaml"
+			if len(args) > 0 {
+				file = args[0]
+			}
+			return configInit(file, flags)
+		},
+		TraverseChildren: true,
+	}
+	addBoolFlag(cmd, "config-force", "", false, "overwrite the existing configuration file")
+	return cmd
+}
+
+// configFlagDefinitions flags of all commands bound to the configuration keys, parent command flags first
+func configFlagDefinitions() []configFlag {
+	keys := map[string]bool{}
+	for _, key := range viper.AllKeys() {
+		keys[key] = true
+	}
+	names := map[string]bool{}
+	var result []configFlag
+	add := func(cmd *cobra.Command, fs *pflag.FlagSet) {
+		fs.VisitAll(func(f *pflag.Flag) {
+			if names[f.Name] || configIgnoredFlags[f.Name] || !keys[f.Name] {
+				return
+			}
+			names[f.Name] = true
+			result = append(result, configFlag{Flag: f, Command: cmd.CommandPath()})
+		})
+	}
+	var walk func(cmd *cobra.Command)
+	walk = func(cmd *cobra.Command) {
+		// flags of the config commands are not the configuration
+		if cmd.Name() == "config" && cmd.Parent() == rootCmd {
+			return
+		}
+		add(cmd, cmd.PersistentFlags())
+		add(cmd, cmd.Flags())
+		for _, c := range cmd.Commands() {
+			walk(c)
+		}
+	}
+	walk(rootCmd)
+	return result
+}
+
+// configKeys keys of the configuration values which are not flags
+func configKeys() []string {
+	return []string{"pipelines"}
+}
+
+// configSchema JSON schema of the configuration file
+func configSchema() map[string]interface{} {
+	flags := map[string]interface{}{}
+	for _, f := range configFlagDefinitions() {
+		flags[f.Flag.Name] = configFlagSchema(f.Flag)
+	}
+
+	properties := map[string]interface{}{}
+	for k, v := range flags {
+		properties[k] = v
+	}
+	properties["pipelines"] = map[string]interface{}{
+		"type": "object",
+		"additionalProperties": map[string]interface{}{
+			"type": "array",
+			"items": map[string]interface{}{
+				"type":                 "object",
+				"additionalProperties": false,
+				"properties": map[string]interface{}{
+					"name":  map[string]interface{}{"type": "string"},
+					"run":   map[string]interface{}{"type": "string", "enum": stringsToInterfaces(pipelineOperationNames())},
+					"shell": map[string]interface{}{"type": "string"},
+					"with": map[string]interface{}{
+						"type":                 "object",
+						"additionalProperties": false,
+						"properties":           flags,
+					},
+					"when": map[string]interface{}{
+						"type":                 "object",
+						"additionalProperties": false,
+						"properties": map[string]interface{}{
+							"branch": map[string]interface{}{"type": "string"},
+							"patch":  map[string]interface{}{"type": "boolean"},
+							"tag":    map[string]interface{}{"type": "boolean"},
+						},
+					},
+				},
+			},
+		},
+	}
+
+	// the branches sections override the values, the include and branches are not allowed in the section
+	root := map[string]interface{}{
+		"include": map[string]interface{}{
+			"oneOf": []interface{}{
+				map[string]interface{}{"type": "string"},
+				map[string]interface{}{"type": "array", "items": map[string]interface{}{"type": "string"}},
+			},
+		},
+		"branches": map[string]interface{}{
+			"type": "object",
+			"additionalProperties": map[string]interface{}{
+				"type":                 "object",
+				"additionalProperties": false,
+				"properties":           properties,
+			},
+		},
+	}
+	for k, v := range properties {
+		root[k] = v
+	}
+	return map[string]interface{}{
+		"$schema":              "https://json-schema.org/draft/2020-12/schema",
+		"type":                 "object",
+		"additionalProperties": false,
+		"properties":           root,
+	}
+}
+
+func configFlagSchema(f *pflag.Flag) map[string]interface{} {
+	switch f.Value.Type() {
+	case "bool":
+		return map[string]interface{}{"type": "boolean"}
+	case "int":
+		return map[string]interface{}{"type": "integer"}
+	case "duration":
+		return map[string]interface{}{"type": "string", "pattern": `^(0|([0-9]+(\.[0-9]+)?(ns|us|µs|ms|s|m|h))+)$`}
+	}
+	return map[string]interface{}{"type": "string"}
+}
+
+func stringsToInterfaces(items []string) []interface{} {
+	result := make([]interface{}, len(items))
+	for i, item := range items {
+		result[i] = item
+	}
+	return result
+}
+
+// configValidate validate the configuration files and the included files, the problems are written to the output
+func configValidate(w io.Writer, files []string) error {
+	if len(files) == 0 {
+		return fmt.Errorf("%w: no configuration file, use --config or create .samo.yaml", tools.ErrInvalidInput)
+	}
+	validated := map[string]bool{}
+	count := 0
+	var validate func(file string) error
+	validate = func(file string) error {
+		if validated[file] {
+			return nil
+		}
+		validated[file] = true
+		data, err := os.ReadFile(file)
+		if err != nil {
+			return fmt.Errorf("%w: error read configuration file %s: %v", tools.ErrInvalidInput, file, err)
+		}
+		problems, err := configProblems(file, data)
+		if err != nil {
+			return err
+		}
+		for _, p := range problems {
+			fmt.Fprintln(w, p.String())
+		}
+		count += len(problems)
+		if len(problems) > 0 {
+			return nil
+		}
+		log.Info("Configuration file is valid.", log.F("file", file))
+
+		values := map[string]interface{}{}
+		if err := yaml.Unmarshal(data, &values); err != nil {
+			return nil
+		}
+		includes, err := configIncludes(file, values["include"])
+		if err != nil {
+			fmt.Fprintln(w, lintProblem{File: file, Message: err.Error()}.String())
+			count++
+			return nil
+		}
+		for _, include := range includes {
+			if err := validate(include); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+	for _, file := range files {
+		if err := validate(file); err != nil {
+			return err
+		}
+	}
+	if count > 0 {
+		return fmt.Errorf("%w: configuration files have %d problems", tools.ErrInvalidInput, count)
+	}
+	return nil
+}
+
+// configProblems validate the content of the configuration file against the schema
+func configProblems(file string, data []byte) ([]lintProblem, error) {
+	node := &yaml.Node{}
+	if err := yaml.Unmarshal(data, node); err != nil {
+		return []lintProblem{{File: file, Message: err.Error()}}, nil
+	}
+	// empty file
+	if len(node.Content) == 0 {
+		return nil, nil
+	}
+	doc := node.Content[0]
+
+	schemaDoc, err := jsonschema.UnmarshalJSON(strings.NewReader(toJSON(configSchema())))
+	if err != nil {
+		return nil, err
+	}
+	compiler := jsonschema.NewCompiler()
+	if err := compiler.AddResource("samo.json", schemaDoc); err != nil {
+		return nil, err
+	}
+	schema, err := compiler.Compile("samo.json")
+	if err != nil {
+		return nil, err
+	}
+
+	instance, err := yamlNodeToJSON(doc)
+	if err != nil {
+		return []lintProblem{{File: file, Line: doc.Line, Message: err.Error()}}, nil
+	}
+	err = schema.Validate(instance)
+	var verr *jsonschema.ValidationError
+	if !errors.As(err, &verr) {
+		return nil, err
+	}
+
+	printer := message.NewPrinter(language.English)
+	keys := append([]string{"include", "branches"}, append(configKeys(), configFlagNames()...)...)
+	var problems []lintProblem
+	for _, cause := range validationLeafs(verr) {
+		if ap, ok := cause.ErrorKind.(*kind.AdditionalProperties); ok {
+			for _, p := range ap.Properties {
+				location := append(append([]string{}, cause.InstanceLocation...), p)
+				msg := fmt.Sprintf("/%s: unknown key '%s'", strings.Join(location, "/"), p)
+				if similar := closestKey(p, keys); len(similar) > 0 {
+					msg += fmt.Sprintf(", did you mean '%s'?", similar)
+				}
+				problems = append(problems, lintProblem{File: file, Line: yamlNodeLine(doc, location), Message: msg})
+			}
+			continue
+		}
+		problems = append(problems, lintProblem{
+			File:    file,
+			Line:    yamlNodeLine(doc, cause.InstanceLocation),
+			Message: "/" + strings.Join(cause.InstanceLocation, "/") + ": " + cause.ErrorKind.LocalizedString(printer),
+		})
+	}
+	sort.SliceStable(problems, func(i, j int) bool {
+		return problems[i].Line < problems[j].Line
+	})
+	return problems, nil
+}
+
+func configFlagNames() []string {
+	var result []string
+	for _, f := range configFlagDefinitions() {
+		result = append(result, f.Flag.Name)
+	}
+	return result
+}
+
+// closestKey the most similar key with the maximum edit distance of 2, empty if there is no similar key
+func closestKey(name string, keys []string) string {
+	result := ""
+	best := 3
+	for _, key := range keys {
+		if d := editDistance(name, key); d < best {
+			best = d
+			result = key
+		}
+	}
+	return result
+}
+
+// editDistance Levenshtein distance of the strings
+func editDistance(a, b string) int {
+	prev := make([]int, len(b)+1)
+	for j := range prev {
+		prev[j] = j
+	}
+	for i := 1; i <= len(a); i++ {
+		cur := make([]int, len(b)+1)
+		cur[0] = i
+		for j := 1; j <= len(b); j++ {
+			cost := 1
+			if a[i-1] == b[j-1] {
+				cost = 0
+			}
+			cur[j] = min(prev[j]+1, cur[j-1]+1, prev[j-1]+cost)
+		}
+		prev = cur
+	}
+	return prev[len(b)]
+}
+
+// configShow write the effective configuration with the source of the values
+func configShow(w io.Writer, flags configShowFlags) error {
+	var values []configValue
+	for _, f := range configFlagDefinitions() {
+		values = append(values, configValue{Key: f.Flag.Name, Value: viper.Get(f.Flag.Name), Source: configSource(f.Flag.Name, f.Flag)})
+	}
+	for _, key := range configKeys() {
+		if viper.IsSet(key) {
+			values = append(values, configValue{Key: key, Value: viper.Get(key), Source: configSource(key, nil)})
+		}
+	}
+	sort.Slice(values, func(i, j int) bool {
+		return values[i].Key < values[j].Key
+	})
+	for i := range values {
+		if log.IsSecretName(values[i].Key) && fmt.Sprint(values[i].Value) != "" {
+			values[i].Value = log.Redacted
+		}
+	}
+
+	if config != nil {
+		log.Info("Configuration", log.F("files", config.Files).F("branch", config.Branch))
+	}
+	switch flags.Format {
+	case "json":
+		data, err := json.MarshalIndent(values, "", "  ")
+		if err != nil {
+			return err
+		}
+		_, err = fmt.Fprintln(w, string(data))
+		return err
+	case "table":
+		tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+		fmt.Fprintln(tw, "KEY\tVALUE\tSOURCE")
+		for _, v := range values {
+			fmt.Fprintf(tw, "%s\t%s\t%s\n", v.Key, configValueString(v.Value), v.Source)
+		}
+		return tw.Flush()
+	}
+	return fmt.Errorf("%w: not supported config format %s, one of table | json", tools.ErrInvalidInput, flags.Format)
+}
+
+// configSource source of the configuration value in the viper order: flag, env, file or branch section, default
+func configSource(key string, f *pflag.Flag) string {
+	if f != nil && f.Changed {
+		return "flag"
+	}
+	if _, exists := os.LookupEnv(configEnv(key)); exists {
+		return "env"
+	}
+	if config != nil {
+		if source, exists := config.Sources[key]; exists {
+			return source
+		}
+	}
+	return "default"
+}
+
+// configEnv environment variable of the configuration key
+func configEnv(key string) string {
+	return "SAMO_" + strings.ToUpper(strings.ReplaceAll(key, "-", "_"))
+}
+
+func configValueString(value interface{}) string {
+	switch value.(type) {
+	case map[string]interface{}, []interface{}:
+		return toJSON(value)
+	}
+	return fmt.Sprint(value)
+}
+
+func toJSON(value interface{}) string {
+	data, err := json.Marshal(value)
+	if err != nil {
+		return fmt.Sprint(value)
+	}
+	return string(data)
+}
+
+// configInit create the commented starter configuration file
+func configInit(file string, flags configInitFlags) error {
+	content := configStarter()
+	if file == "-" {
+		_, err := fmt.Fprint(os.Stdout, content)
+		return err
+	}
+	if tools.Exists(file) && !flags.Force {
+		return fmt.Errorf("%w: configuration file %s already exists, use --config-force to overwrite it", tools.ErrPrecondition, file)
+	}
+	if err := os.WriteFile(file, []byte(content), 0644); err != nil {
+		return fmt.Errorf("error write configuration file %s: %w", file, err)
+	}
+	log.Info("Configuration file created", log.F("file", file))
+	return nil
+}
+
+// configStarter commented configuration with the default values of all flags grouped by the command
+func configStarter() string {
+	var sb strings.Builder
+	sb.WriteString("# samo configuration file, the keys are the flag names.\n")
+	sb.WriteString("# Order of the values: flags, SAMO_* environment variables, this file, defaults.\n")
+	sb.WriteString("# Validate the file: samo config validate\n")
+
+	command := ""
+	for _, f := range configFlagDefinitions() {
+		if f.Command != command {
+			command = f.Command
+			sb.WriteString("\n# --- " + command + " ---\n")
+		}
+		for _, line := range strings.Split(strings.TrimSpace(f.Flag.Usage), "\n") {
+			sb.WriteString(strings.TrimRight("# "+strings.TrimSpace(line), " ") + "\n")
+		}
+		sb.WriteString("# " + configDefault(f.Flag) + "\n")
+	}
+
+	sb.WriteString(`
+# --- samo run ---
+# pipelines:
+#   build:
+#     - run: docker build
+#       with:
+#         docker-build-push: true
+#     - name: notify
+#       shell: echo "build {{ .Version }}"
+#       when:
+#         branch: ^main$
+`)
+	return sb.String()
+}
+
+// configDefault yaml key with the default value of the flag
+func configDefault(f *pflag.Flag) string {
+	var value interface{} = f.DefValue
+	switch f.Value.Type() {
+	case "bool", "int":
+		value = yamlRaw(f.DefValue)
+	}
+	data, err := yaml.Marshal(map[string]interface{}{f.Name: value})
+	if err != nil {
+		return f.Name + ": " + f.DefValue
+	}
+	return strings.TrimSpace(string(data))
+}
+
+// yamlRaw yaml value of the bool and int default
+func yamlRaw(value string) interface{} {
+	var result interface{}
+	if err := yaml.Unmarshal([]byte(value), &result); err != nil {
+		return value
+	}
+	return result
+}
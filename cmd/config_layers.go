@@ -0,0 +1,273 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/lorislab/samo/log"
+	"github.com/lorislab/samo/tools"
+	"github.com/mitchellh/go-homedir"
+	"gopkg.in/yaml.v3"
+)
+
+// configFileName name of the configuration file in the home directory and the repository root
+const configFileName = ".samo.yaml"
+
+// configLayer values of the configuration file
+type configLayer struct {
+	File     string
+	Values   map[string]interface{}
+	Branches []configBranch
+}
+
+// configBranch values of the branches section which override the configuration on the matching branch
+type configBranch struct {
+	Pattern string
+	Values  map[string]interface{}
+}
+
+// configuration result of the layered configuration
+type configuration struct {
+	// Files loaded files in the merge order, the included files are before the including file
+	Files []string
+	// Values merged values of all layers
+	Values map[string]interface{}
+	// Sources the source of the top level keys
+	Sources map[string]string
+	// Branch current branch of the branches sections
+	Branch string
+}
+
+// configLayerFiles configuration files in the merge order: $HOME, the repository root and the --config file.
+// Without the repository the current directory is used. The missing home and repository files are ignored.
+func configLayerFiles(configFile string) []string {
+	var files []string
+	exists := func(file string) bool {
+		for _, f := range files {
+			if f == file {
+				return true
+			}
+		}
+		return false
+	}
+	if home, err := homedir.Dir(); err != nil {
+		log.Error("error read home dir", log.E(err))
+	} else if file := filepath.Join(home, configFileName); fileExists(file) {
+		files = append(files, file)
+	}
+	if dir, err := os.Getwd(); err == nil {
+		if file := filepath.Join(repositoryRoot(dir), configFileName); fileExists(file) && !exists(file) {
+			files = append(files, file)
+		}
+	}
+	if len(configFile) > 0 {
+		if abs, err := filepath.Abs(configFile); err == nil {
+			configFile = abs
+		}
+		if !exists(configFile) {
+			files = append(files, configFile)
+		}
+	}
+	return files
+}
+
+// repositoryRoot the first parent directory with the .git, the directory if it is not in the repository
+func repositoryRoot(dir string) string {
+	for current := dir; ; {
+		if _, err := os.Stat(filepath.Join(current, ".git")); err == nil {
+			return current
+		}
+		parent := filepath.Dir(current)
+		if parent == current {
+			return dir
+		}
+		current = parent
+	}
+}
+
+func fileExists(file string) bool {
+	info, err := os.Stat(file)
+	return err == nil && !info.IsDir()
+}
+
+// loadConfiguration load and merge the configuration files. The layers are merged in the order of the files,
+// the included files before the including file. The maps are merged, the other values are replaced.
+// The matching branches sections are applied at the end in the same order.
+func loadConfiguration(files []string, branch func() (string, error)) (*configuration, error) {
+	var layers []configLayer
+	for _, file := range files {
+		if err := loadConfigLayer(file, nil, &layers); err != nil {
+			return nil, err
+		}
+	}
+
+	result := &configuration{Values: map[string]interface{}{}, Sources: map[string]string{}}
+	branches := false
+	for _, layer := range layers {
+		result.Files = append(result.Files, layer.File)
+		mergeConfigValues(result.Values, layer.Values, "file:"+layer.File, result.Sources)
+		branches = branches || len(layer.Branches) > 0
+	}
+	if !branches {
+		return result, nil
+	}
+
+	current, err := branch()
+	if err != nil {
+		log.Warn("Error read current branch, configuration branches sections are ignored", log.E(err))
+		return result, nil
+	}
+	result.Branch = current
+	for _, layer := range layers {
+		for _, b := range layer.Branches {
+			// the pattern matches the whole branch name
+			regex, err := regexp.Compile("^(?:" + b.Pattern + ")$")
+			if err != nil {
+				return nil, fmt.Errorf("%w: invalid branches pattern '%s' of the configuration file %s: %v", tools.ErrInvalidInput, b.Pattern, layer.File, err)
+			}
+			if regex.MatchString(current) {
+				log.Debug("Configuration branch", log.F("file", layer.File).F("pattern", b.Pattern).F("branch", current))
+				mergeConfigValues(result.Values, b.Values, "branch("+b.Pattern+"):"+layer.File, result.Sources)
+			}
+		}
+	}
+	return result, nil
+}
+
+// loadConfigLayer load the configuration file with the included files, the stack detects the include cycles
+func loadConfigLayer(file string, stack []string, layers *[]configLayer) error {
+	for _, f := range stack {
+		if f == file {
+			return fmt.Errorf("%w: include cycle of the configuration files %s -> %s", tools.ErrInvalidInput, strings.Join(stack, " -> "), file)
+		}
+	}
+	data, err := os.ReadFile(file)
+	if err != nil {
+		return fmt.Errorf("%w: error read configuration file %s: %v", tools.ErrInvalidInput, file, err)
+	}
+	node := &yaml.Node{}
+	if err := yaml.Unmarshal(data, node); err != nil {
+		return fmt.Errorf("%w: error parse configuration file %s: %v", tools.ErrInvalidInput, file, err)
+	}
+	layer := configLayer{File: file, Values: map[string]interface{}{}}
+	if len(node.Content) == 0 {
+		*layers = append(*layers, layer)
+		return nil
+	}
+	doc := node.Content[0]
+	if err := doc.Decode(&layer.Values); err != nil {
+		return fmt.Errorf("%w: error parse configuration file %s: %v", tools.ErrInvalidInput, file, err)
+	}
+
+	includes, err := configIncludes(file, layer.Values["include"])
+	if err != nil {
+		return err
+	}
+	for _, include := range includes {
+		if err := loadConfigLayer(include, append(stack, file), layers); err != nil {
+			return err
+		}
+	}
+
+	// the order of the branches sections is the order in the file
+	for i := 0; i+1 < len(doc.Content); i += 2 {
+		if doc.Content[i].Value != "branches" {
+			continue
+		}
+		section := doc.Content[i+1]
+		if section.Kind != yaml.MappingNode {
+			return fmt.Errorf("%w: branches of the configuration file %s must be the map of the branch patterns", tools.ErrInvalidInput, file)
+		}
+		for j := 0; j+1 < len(section.Content); j += 2 {
+			b := configBranch{Pattern: section.Content[j].Value, Values: map[string]interface{}{}}
+			if err := section.Content[j+1].Decode(&b.Values); err != nil {
+				return fmt.Errorf("%w: error parse branches '%s' of the configuration file %s: %v", tools.ErrInvalidInput, b.Pattern, file, err)
+			}
+			layer.Branches = append(layer.Branches, b)
+		}
+	}
+	delete(layer.Values, "include")
+	delete(layer.Values, "branches")
+	*layers = append(*layers, layer)
+	return nil
+}
+
+// configIncludes the files of the include value, the relative paths are relative to the including file.
+// The directory includes all yaml files of the directory in the name order.
+func configIncludes(file string, value interface{}) ([]string, error) {
+	var items []string
+	switch v := value.(type) {
+	case nil:
+		return nil, nil
+	case string:
+		items = append(items, v)
+	case []interface{}:
+		for _, item := range v {
+			s, ok := item.(string)
+			if !ok {
+				return nil, fmt.Errorf("%w: include of the configuration file %s must be the list of the paths", tools.ErrInvalidInput, file)
+			}
+			items = append(items, s)
+		}
+	default:
+		return nil, fmt.Errorf("%w: include of the configuration file %s must be the path or the list of the paths", tools.ErrInvalidInput, file)
+	}
+
+	var result []string
+	for _, item := range items {
+		path, err := homedir.Expand(item)
+		if err != nil {
+			return nil, fmt.Errorf("%w: invalid include %s of the configuration file %s: %v", tools.ErrInvalidInput, item, file, err)
+		}
+		if !filepath.IsAbs(path) {
+			path = filepath.Join(filepath.Dir(file), path)
+		}
+		info, err := os.Stat(path)
+		if err != nil {
+			return nil, fmt.Errorf("%w: include %s of the configuration file %s does not exist", tools.ErrInvalidInput, item, file)
+		}
+		if !info.IsDir() {
+			result = append(result, path)
+			continue
+		}
+		entries, err := os.ReadDir(path)
+		if err != nil {
+			return nil, fmt.Errorf("%w: error read include directory %s: %v", tools.ErrInvalidInput, path, err)
+		}
+		var names []string
+		for _, e := range entries {
+			if ext := filepath.Ext(e.Name()); !e.IsDir() && (ext == ".yaml" || ext == ".yml") {
+				names = append(names, e.Name())
+			}
+		}
+		sort.Strings(names)
+		for _, name := range names {
+			result = append(result, filepath.Join(path, name))
+		}
+	}
+	return result, nil
+}
+
+// mergeConfigValues merge the source values to the target values, the maps are merged recursively,
+// the other values are replaced. The source of the top level keys is updated.
+func mergeConfigValues(target, values map[string]interface{}, source string, sources map[string]string) {
+	for k, v := range values {
+		if sources != nil {
+			sources[k] = source
+		}
+		src, srcMap := v.(map[string]interface{})
+		dst, dstMap := target[k].(map[string]interface{})
+		if srcMap && dstMap {
+			merged := map[string]interface{}{}
+			mergeConfigValues(merged, dst, source, nil)
+			mergeConfigValues(merged, src, source, nil)
+			target[k] = merged
+			continue
+		}
+		target[k] = v
+	}
+}
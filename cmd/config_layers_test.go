@@ -0,0 +1,36 @@
+package cmd
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestLoadConfigurationBranches(t *testing.T) {
+	file := filepath.Join(t.TempDir(), ".samo.yaml")
+	content := "docker-group: base\nbranches:\n  main:\n    docker-group: main\n  release/.*:\n    docker-group: release\n"
+	if err := os.WriteFile(file, []byte(content), 0644); err != nil {
+		t.Fatal(err)
+	}
+	tests := []struct {
+		branch   string
+		expected string
+	}{
+		{branch: "main", expected: "main"},
+		{branch: "maintenance", expected: "base"},
+		{branch: "feature/main-x", expected: "base"},
+		{branch: "release/1.2", expected: "release"},
+		{branch: "fix/release/1.2", expected: "base"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.branch, func(t *testing.T) {
+			config, err := loadConfiguration([]string{file}, func() (string, error) { return tt.branch, nil })
+			if err != nil {
+				t.Fatal(err)
+			}
+			if value := config.Values["docker-group"]; value != tt.expected {
+				t.Errorf("docker-group %v, expected %s", value, tt.expected)
+			}
+		})
+	}
+}
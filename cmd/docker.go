@@ -1,22 +1,21 @@
 package cmd
 
 import (
-	"regexp"
-	"strings"
-	"time"
+	"context"
 
 	"github.com/lorislab/samo/log"
-	"github.com/lorislab/samo/tools"
+	"github.com/lorislab/samo/pkg/samo"
 	"github.com/spf13/cobra"
 )
 
 type dockerFlags struct {
-	Project                  projectFlags `mapstructure:",squash"`
-	Registry                 string       `mapstructure:"docker-registry"`
-	Group                    string       `mapstructure:"docker-group"`
-	Repo                     string       `mapstructure:"docker-repository"`
-	TagListTemplate          string       `mapstructure:"docker-tag-template-list"`
-	SkipOpenContainersLabels bool         `mapstructure:"docker-skip-opencontainers-labels"`
+	Project                  projectFlags    `mapstructure:",squash"`
+	Registry                 string          `mapstructure:"docker-registry"`
+	Group                    string          `mapstructure:"docker-group"`
+	Repo                     string          `mapstructure:"docker-repository"`
+	TagListTemplate          string          `mapstructure:"docker-tag-template-list"`
+	SkipOpenContainersLabels bool            `mapstructure:"docker-skip-opencontainers-labels"`
+	Auth                     dockerAuthFlags `mapstructure:",squash"`
 }
 
 func createDockerCmd() *cobra.Command {
@@ -35,6 +34,7 @@ func createDockerCmd() *cobra.Command {
 	Values: `+templateValues+`
 	Example: {{ .Version }},latest,{{ .Hash }}
 	`)
+	addRegistryAuthFlags(cmd, "docker", "SAMO_DOCKER", "docker registry")
 
 	addChildCmd(cmd, createDockerBuildCmd())
 	addChildCmd(cmd, createDockerPushCmd())
@@ -47,103 +47,26 @@ func createDockerCmd() *cobra.Command {
 }
 
 func dockerImage(project *Project, registry, group, repository string) string {
-	dockerImage := repository
-	if len(dockerImage) == 0 {
-		dockerImage = project.Name()
-	}
-	if len(group) > 0 {
-		if !strings.HasSuffix(group, `/`) {
-			group = group + "/"
-		}
-		dockerImage = group + dockerImage
-	}
-	if len(registry) > 0 {
-		dockerImage = registry + "/" + dockerImage
-	}
-	return dockerImage
-}
-
-func dockerTags(dockerImage string, pro *Project, dockerTags string) []string {
-	tagTemplate := tools.Template(pro, dockerTags)
-	items := strings.Split(tagTemplate, ",")
-
-	var tags []string
-	for _, tag := range items {
-		var t = dockerReplaceTag(tag)
-		if len(dockerImage) > 0 {
-			tags = append(tags, dockerImageTag(dockerImage, t))
-		} else {
-			tags = append(tags, t)
-		}
-	}
-	return tags
-}
-
-// A tag name must be valid ASCII and may contain lowercase and uppercase letters, digits, underscores,
-// periods and hyphens. A tag name may not start with a period or a hyphen and may contain a maximum
-// of 128 characters.
-// [a-z][A-Z][0-9]_.-
-var dockerTagRegex = regexp.MustCompile(`[^a-zA-Z0-9_.-]+`)
-
-func dockerReplaceTag(tag string) string {
-	return dockerTagRegex.ReplaceAllString(tag, "_")
-}
-
-func dockerImageTag(dockerImage, tag string) string {
-	return dockerImage + ":" + tag
-}
-
-func dockerImageTagTemplate(pro *Project, dockerImage, template string) string {
-	tagTemplate := tools.Template(pro, template)
-	return dockerImage + ":" + tagTemplate
+	return samo.DockerImage(project, registry, group, repository)
 }
 
-func dockerImagePush(image string, tags []string, skip bool) {
+func dockerImagePush(ctx context.Context, image string, tags []string, skip bool) error {
 	log.Info("Push docker image tags", log.Fields{"image": image, "tags": tags})
 	if skip {
 		log.Info("Skip docker push", log.F("image", image))
 	} else {
-		for _, tag := range tags {
-			tools.ExecCmd("docker", "push", tag)
+		if err := samo.Run(ctx, samo.DockerPush(tags)...); err != nil {
+			return err
 		}
 	}
 	log.Info("Push docker image done!", log.Fields{"image": image, "tags": tags})
+	return nil
 }
 
-func dockerLabels(project *Project, skipLabels bool, skipOpenContainersLabels bool, customLabels string) map[string]string {
-
-	result := map[string]string{}
-
-	created := time.Now().Format(time.RFC3339)
-
-	// add labels
-	if !skipLabels {
-		result["samo.project.revision"] = project.Hash()
-		result["samo.project.version"] = project.Version()
-		result["samo.project.created"] = created
-	}
-
-	// add open-containers labels
-	if !skipOpenContainersLabels {
-		result["org.opencontainers.image.created"] = created
-		result["org.opencontainers.image.title"] = project.Name()
-		result["org.opencontainers.image.description"] = project.Description()
-		result["org.opencontainers.image.revision"] = project.Hash()
-		result["org.opencontainers.image.version"] = project.Version()
-		result["org.opencontainers.image.source"] = project.Url()
-	}
-
-	// add custom labels
-	if len(customLabels) > 0 {
-		labelTemplate := tools.Template(project, customLabels)
-		labels := strings.Split(labelTemplate, ",")
-		for _, label := range labels {
-			kv := strings.Split(label, "=")
-			if len(kv) > 1 {
-				result[kv[0]] = kv[1]
-			}
-		}
-	}
-
-	return result
+func dockerLabels(project *Project, flags dockerFlags) (map[string]string, error) {
+	return samo.DockerLabels(project, samo.DockerLabelOptions{
+		SkipSamo:           flags.Project.SkipLabels,
+		SkipOpenContainers: flags.SkipOpenContainersLabels,
+		Template:           flags.Project.LabelTemplate,
+	})
 }
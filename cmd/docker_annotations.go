@@ -20,12 +20,16 @@ func createDockerAnnotationsCmd() *cobra.Command {
 		Use:   "annotations",
 		Short: "Create list of docker image annotations",
 		Long:  `Create list of docker image annotations`,
-		Run: func(cmd *cobra.Command, args []string) {
-
+		RunE: func(cmd *cobra.Command, args []string) error {
 			flags := dockerAnnotationFlags{}
-			readOptions(&flags)
-			project := loadProject(flags.Docker.Project)
-			dockerAnnotationsCmd(project, flags)
+			if err := readOptions(&flags); err != nil {
+				return err
+			}
+			project, err := loadProject(cmd.Context(), flags.Docker.Project)
+			if err != nil {
+				return err
+			}
+			return dockerAnnotationsCmd(project, flags)
 		},
 		TraverseChildren: true,
 	}
@@ -41,36 +45,42 @@ Default multi-lines annotation template:
 	return cmd
 }
 
-func dockerAnnotationsCmd(project *Project, flags dockerAnnotationFlags) {
+func dockerAnnotationsCmd(project *Project, flags dockerAnnotationFlags) error {
 
-	annotations := dockerLabels(project, flags.Docker.Project.SkipLabels, flags.Docker.SkipOpenContainersLabels, flags.Docker.Project.LabelTemplate)
+	annotations, err := dockerLabels(project, flags.Docker)
+	if err != nil {
+		return err
+	}
 
 	var template = flags.AnnotationTemplate
 	if flags.IterableTemplate == "one-line" {
 		if len(template) == 0 {
 			template = defaultTemplateOnLine
 		}
-		templateOneLine(annotations, template)
-	} else {
-		if len(template) == 0 {
-			template = defaultTemplateMultiLines
-		}
-		templateMultiLines(annotations, template)
+		return templateOneLine(annotations, template)
 	}
+	if len(template) == 0 {
+		template = defaultTemplateMultiLines
+	}
+	return templateMultiLines(annotations, template)
 }
 
 type A struct {
 	Annotations []I
 }
 
-func templateOneLine(annotations map[string]string, template string) {
+func templateOneLine(annotations map[string]string, template string) error {
 
 	var tmp []I
 	for k, v := range annotations {
 		tmp = append(tmp, I{k, v})
 	}
-	output := tools.Template(A{tmp}, template)
+	output, err := tools.Template(A{tmp}, template)
+	if err != nil {
+		return err
+	}
 	fmt.Printf("%s\n", output)
+	return nil
 }
 
 type I struct {
@@ -78,10 +88,13 @@ type I struct {
 	Value string
 }
 
-func templateMultiLines(annotations map[string]string, template string) {
+func templateMultiLines(annotations map[string]string, template string) error {
 	var output []string
 	for k, v := range annotations {
-		label := tools.Template(I{k, v}, template)
+		label, err := tools.Template(I{k, v}, template)
+		if err != nil {
+			return err
+		}
 		output = append(output, label)
 	}
 
@@ -89,4 +102,5 @@ func templateMultiLines(annotations map[string]string, template string) {
 	for _, label := range output {
 		fmt.Printf("%s\n", label)
 	}
+	return nil
 }
@@ -1,7 +1,11 @@
 package cmd
 
 import (
+	"context"
+	"fmt"
+
 	"github.com/lorislab/samo/log"
+	"github.com/lorislab/samo/pkg/samo"
 	"github.com/lorislab/samo/tools"
 	"github.com/spf13/cobra"
 )
@@ -27,12 +31,16 @@ func createDockerBuildCmd() *cobra.Command {
 		Use:   "build",
 		Short: "Build the docker image of the project",
 		Long:  `Build the docker image of the project`,
-		Run: func(cmd *cobra.Command, args []string) {
-
+		RunE: func(cmd *cobra.Command, args []string) error {
 			flags := dockerBuildFlags{}
-			readOptions(&flags)
-			project := loadProject(flags.Docker.Project)
-			dockerBuild(project, flags)
+			if err := readOptions(&flags); err != nil {
+				return err
+			}
+			project, err := loadProject(cmd.Context(), flags.Docker.Project)
+			if err != nil {
+				return err
+			}
+			return dockerBuild(cmd.Context(), project, flags)
 		},
 		TraverseChildren: true,
 	}
@@ -54,7 +62,7 @@ func createDockerBuildCmd() *cobra.Command {
 }
 
 // DockerBuild build docker image of the project
-func dockerBuild(project *Project, flags dockerBuildFlags) {
+func dockerBuild(ctx context.Context, project *Project, flags dockerBuildFlags) error {
 
 	dockerfile := flags.File
 	if len(dockerfile) <= 0 {
@@ -65,11 +73,14 @@ func dockerBuild(project *Project, flags dockerBuildFlags) {
 	}
 
 	if !tools.Exists(flags.File) {
-		log.Fatal("Dockerfile does not exists!", log.F("file", dockerfile))
+		return fmt.Errorf("%w: dockerfile %s does not exists", tools.ErrPrecondition, dockerfile)
 	}
 
 	dockerImage := dockerImage(project, flags.Docker.Registry, flags.Docker.Group, flags.Docker.Repo)
-	tags := dockerTags(dockerImage, project, flags.Docker.TagListTemplate)
+	tags, err := samo.DockerTags(project, dockerImage, flags.Docker.TagListTemplate)
+	if err != nil {
+		return err
+	}
 
 	if !flags.SkipDevBuild {
 		tags = append(tags, project.Name()+":latest")
@@ -77,60 +88,40 @@ func dockerBuild(project *Project, flags dockerBuildFlags) {
 
 	log.Info("Build docker image", log.Fields{"image": dockerImage, "tags": tags})
 
-	var command []string
-	if flags.BuildX {
-		command = append(command, "buildx")
-	}
-	command = append(command, "build")
-	if !flags.SkipPull {
-		command = append(command, "--pull")
-	}
-
-	// Removing intermediate container
-	if !flags.SkipRemoveBuild {
-		command = append(command, "--rm")
-	}
-	if len(flags.Provenance) > 0 {
-		command = append(command, "--provenance", flags.Provenance)
-	}
-	if len(flags.Platform) > 0 {
-		command = append(command, "--platform", flags.Platform)
-	}
 	// create labels
-	labels := dockerLabels(project, flags.Docker.Project.SkipLabels, flags.Docker.SkipOpenContainersLabels, flags.Docker.Project.LabelTemplate)
-	for key, value := range labels {
-		command = append(command, "--label", key+"="+value)
+	labels, err := dockerLabels(project, flags.Docker)
+	if err != nil {
+		return err
 	}
 
-	// create annotations
-	if flags.BuildX && flags.AddLabelsAnnotation {
-		for key, value := range labels {
-			command = append(command, "--annotation", flags.PrefixLabelsAnnotation+key+"="+value)
-		}
+	// the build pulls the base images and pushes the image with buildx
+	if err := registryLogin(ctx, registryDocker, dockerAuth(flags.Docker.Registry, flags.Docker.Auth)); err != nil {
+		return err
 	}
 
-	// add tags
-	for _, tag := range tags {
-		command = append(command, "-t", tag)
+	build := samo.DockerBuild{
+		File:             dockerfile,
+		Context:          flags.Context,
+		Platform:         flags.Platform,
+		Provenance:       flags.Provenance,
+		Tags:             tags,
+		Labels:           labels,
+		BuildX:           flags.BuildX,
+		SkipPull:         flags.SkipPull,
+		SkipRemove:       flags.SkipRemoveBuild,
+		Annotations:      flags.AddLabelsAnnotation,
+		AnnotationPrefix: flags.PrefixLabelsAnnotation,
+		Push:             flags.BuildX && flags.BuildPush,
 	}
-
-	// add dockerfile and dockerfile profile
-	command = append(command, "-f", dockerfile)
-
-	// push images for buildx
-	if flags.BuildX && flags.BuildPush {
-		command = append(command, "--push")
+	if err := samo.Run(ctx, build.Commands()...); err != nil {
+		return err
 	}
 
-	// set docker context
-	command = append(command, flags.Context)
-	// execute command
-	tools.ExecCmd("docker", command...)
-
 	log.Info("Docker build done!", log.Fields{"image": dockerImage, "tags": tags})
 
 	// for none buildx we need to push it manually
 	if !flags.BuildX && flags.BuildPush {
-		dockerImagePush(dockerImage, tags, flags.Docker.Project.SkipPush)
+		return dockerImagePush(ctx, dockerImage, tags, flags.Docker.Project.SkipPush)
 	}
+	return nil
 }
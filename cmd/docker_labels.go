@@ -16,12 +16,16 @@ func createDockerLabelsCmd() *cobra.Command {
 		Use:   "labels",
 		Short: "Create list of docker image labels",
 		Long:  `Create list of docker image labels`,
-		Run: func(cmd *cobra.Command, args []string) {
-
+		RunE: func(cmd *cobra.Command, args []string) error {
 			flags := dockerLabelFlags{}
-			readOptions(&flags)
-			project := loadProject(flags.Docker.Project)
-			dockerLabelsCmd(project, flags)
+			if err := readOptions(&flags); err != nil {
+				return err
+			}
+			project, err := loadProject(cmd.Context(), flags.Docker.Project)
+			if err != nil {
+				return err
+			}
+			return dockerLabelsCmd(project, flags)
 		},
 		TraverseChildren: true,
 	}
@@ -36,13 +40,19 @@ type T struct {
 	Value string
 }
 
-func dockerLabelsCmd(project *Project, flags dockerLabelFlags) {
+func dockerLabelsCmd(project *Project, flags dockerLabelFlags) error {
 
-	labels := dockerLabels(project, flags.Docker.Project.SkipLabels, flags.Docker.SkipOpenContainersLabels, flags.Docker.Project.LabelTemplate)
+	labels, err := dockerLabels(project, flags.Docker)
+	if err != nil {
+		return err
+	}
 	var output []string
 
 	for k, v := range labels {
-		label := tools.Template(T{k, v}, flags.LabelTemplate)
+		label, err := tools.Template(T{k, v}, flags.LabelTemplate)
+		if err != nil {
+			return err
+		}
 		output = append(output, label)
 	}
 
@@ -50,5 +60,5 @@ func dockerLabelsCmd(project *Project, flags dockerLabelFlags) {
 	for _, label := range output {
 		fmt.Printf("%s\n", label)
 	}
-
+	return nil
 }
@@ -1,6 +1,9 @@
 package cmd
 
 import (
+	"context"
+
+	"github.com/lorislab/samo/pkg/samo"
 	"github.com/spf13/cobra"
 )
 
@@ -9,12 +12,16 @@ func createDockerPushCmd() *cobra.Command {
 		Use:   "push",
 		Short: "Push the docker image of the project",
 		Long:  `Push the docker image of the project`,
-		Run: func(cmd *cobra.Command, args []string) {
-
+		RunE: func(cmd *cobra.Command, args []string) error {
 			flags := dockerFlags{}
-			readOptions(&flags)
-			project := loadProject(flags.Project)
-			dockerPush(project, flags)
+			if err := readOptions(&flags); err != nil {
+				return err
+			}
+			project, err := loadProject(cmd.Context(), flags.Project)
+			if err != nil {
+				return err
+			}
+			return dockerPush(cmd.Context(), project, flags)
 		},
 		TraverseChildren: true,
 	}
@@ -22,8 +29,16 @@ func createDockerPushCmd() *cobra.Command {
 	return cmd
 }
 
-func dockerPush(project *Project, flags dockerFlags) {
+func dockerPush(ctx context.Context, project *Project, flags dockerFlags) error {
 	dockerImage := dockerImage(project, flags.Registry, flags.Group, flags.Repo)
-	tags := dockerTags(dockerImage, project, flags.TagListTemplate)
-	dockerImagePush(dockerImage, tags, flags.Project.SkipPush)
+	tags, err := samo.DockerTags(project, dockerImage, flags.TagListTemplate)
+	if err != nil {
+		return err
+	}
+	if !flags.Project.SkipPush {
+		if err := registryLogin(ctx, registryDocker, dockerAuth(flags.Registry, flags.Auth)); err != nil {
+			return err
+		}
+	}
+	return dockerImagePush(ctx, dockerImage, tags, flags.Project.SkipPush)
 }
@@ -1,19 +1,24 @@
 package cmd
 
 import (
+	"context"
+	"fmt"
+
 	"github.com/lorislab/samo/log"
+	"github.com/lorislab/samo/pkg/samo"
 	"github.com/lorislab/samo/tools"
 	"github.com/spf13/cobra"
 )
 
 type dockerReleaseFlags struct {
-	Docker          dockerFlags `mapstructure:",squash"`
-	ReleaseRegistry string      `mapstructure:"docker-release-registry"`
-	ReleaseGroup    string      `mapstructure:"docker-release-group"`
-	ReleaseRepo     string      `mapstructure:"docker-release-repository"`
-	ReleaseTags     string      `mapstructure:"docker-release-tags"`
-	ReleaseImageTag string      `mapstructure:"docker-release-image-tag"`
-	ImageTools      bool        `mapstructure:"docker-release-image-tools"`
+	Docker          dockerFlags            `mapstructure:",squash"`
+	ReleaseRegistry string                 `mapstructure:"docker-release-registry"`
+	ReleaseGroup    string                 `mapstructure:"docker-release-group"`
+	ReleaseRepo     string                 `mapstructure:"docker-release-repository"`
+	ReleaseTags     string                 `mapstructure:"docker-release-tags"`
+	ReleaseImageTag string                 `mapstructure:"docker-release-image-tag"`
+	ImageTools      bool                   `mapstructure:"docker-release-image-tools"`
+	ReleaseAuth     dockerReleaseAuthFlags `mapstructure:",squash"`
 }
 
 func createDockerReleaseCmd() *cobra.Command {
@@ -21,11 +26,16 @@ func createDockerReleaseCmd() *cobra.Command {
 		Use:   "release",
 		Short: "Release the docker image and push to release registry",
 		Long:  `Release the docker image and push to release registry`,
-		Run: func(cmd *cobra.Command, args []string) {
+		RunE: func(cmd *cobra.Command, args []string) error {
 			flags := dockerReleaseFlags{}
-			readOptions(&flags)
-			project := loadProject(flags.Docker.Project)
-			dockerRelease(project, flags)
+			if err := readOptions(&flags); err != nil {
+				return err
+			}
+			project, err := loadProject(cmd.Context(), flags.Docker.Project)
+			if err != nil {
+				return err
+			}
+			return dockerRelease(cmd.Context(), project, flags)
 		},
 		TraverseChildren: true,
 	}
@@ -35,23 +45,31 @@ func createDockerReleaseCmd() *cobra.Command {
 	addStringFlag(cmd, "docker-release-repository", "", "", "the docker release repository. Default value project name.")
 	addStringFlag(cmd, "docker-release-tags", "", "{{ .Release }}", "the docker release tags. Default value release version.")
 	addBoolFlag(cmd, "docker-release-image-tools", "", false, "buildx imagetools create a new image based on source images")
+	addRegistryAuthFlags(cmd, "docker-release", "SAMO_DOCKER_RELEASE", "docker release registry. Default the docker registry credentials for the same registry")
 	addStringFlag(cmd, "docker-release-image-tag", "", "{{ .Version }}", "the docker image tag use for release. Default release candidate docker image tag.")
 	return cmd
 }
 
-func dockerRelease(project *Project, flags dockerReleaseFlags) {
+func dockerRelease(ctx context.Context, project *Project, flags dockerReleaseFlags) error {
 
 	if project.Count() != "0" || len(project.Tag()) == 0 {
-		log.Fatal("Can not created docker release. Missing tag on current commit",
-			log.Fields{"version": project.Version(), "hash": project.Hash(), "count": project.Count(), "tag": project.Tag()})
+		return fmt.Errorf("%w: can not created docker release, missing tag on current commit (version: %s, hash: %s, count: %s, tag: %s)",
+			tools.ErrNoTag, project.Version(), project.Hash(), project.Count(), project.Tag())
+	}
+
+	if err := verifyReleaseTag(ctx, project, flags.Docker.Project); err != nil {
+		return err
 	}
 
 	// switch back to rc version
-	project.switchBackToReleaseCandidate()
+	project.SwitchBackToReleaseCandidate()
 	log.Info("Create docker release", log.Fields{"version": project.Version(), "release": project.Release()})
 
 	dockerPullImage := dockerImage(project, flags.Docker.Registry, flags.Docker.Group, flags.Docker.Repo)
-	imagePull := dockerImageTagTemplate(project, dockerPullImage, flags.ReleaseImageTag)
+	imagePull, err := samo.DockerImageTagTemplate(project, dockerPullImage, flags.ReleaseImageTag)
+	if err != nil {
+		return err
+	}
 
 	log.Info("Docker image", log.F("image", imagePull))
 
@@ -68,54 +86,54 @@ func dockerRelease(project *Project, flags dockerReleaseFlags) {
 
 	// release docker registry
 	dockerPushImage := dockerImage(project, flags.ReleaseRegistry, flags.ReleaseGroup, flags.ReleaseRepo)
-	dockerPushImageTags := dockerTags(dockerPushImage, project, flags.ReleaseTags)
-
-	if flags.ImageTools {
-		dockerReleaseImageTools(flags.Docker.Project.SkipPush, imagePull, dockerPushImageTags)
-	} else {
-		dockerReleasePullPush(flags.Docker.Project.SkipPush, imagePull, dockerPushImage, dockerPushImageTags)
+	dockerPushImageTags, err := samo.DockerTags(project, dockerPushImage, flags.ReleaseTags)
+	if err != nil {
+		return err
 	}
-}
-
-func dockerReleaseImageTools(skip bool, imagePull string, dockerPushImageTags []string) {
 
-	var command []string
-
-	// buildx imagetools create
-	command = append(command, "buildx", "imagetools", "create")
-
-	// dry run
-	if skip {
-		command = append(command, "--dry-run")
+	// login to the build registry for the pull and to the release registry for the push
+	if err := registryLogin(ctx, registryDocker, dockerAuth(flags.Docker.Registry, flags.Docker.Auth)); err != nil {
+		return err
 	}
-
-	// add new tags
-	for _, imagePush := range dockerPushImageTags {
-		command = append(command, "-t", imagePush)
+	releaseLogin := func() error {
+		if flags.Docker.Project.SkipPush {
+			return nil
+		}
+		credentials, err := dockerReleaseAuth(flags.ReleaseRegistry, flags.ReleaseAuth).Credentials(ctx)
+		if err != nil || credentials == nil {
+			return err
+		}
+		return registryLoginCredentials(ctx, registryDocker, *credentials)
 	}
 
-	// add remote repository image
-	command = append(command, imagePull)
-
-	// execute command
-	tools.ExecCmd("docker", command...)
+	if flags.ImageTools {
+		if err := releaseLogin(); err != nil {
+			return err
+		}
+		return samo.Run(ctx, samo.DockerImageTools(imagePull, dockerPushImageTags, flags.Docker.Project.SkipPush))
+	}
+	return dockerReleasePullPush(ctx, flags.Docker.Project.SkipPush, imagePull, dockerPushImage, dockerPushImageTags, releaseLogin)
 }
 
 // deprecated
-func dockerReleasePullPush(skip bool, imagePull string, dockerPushImage string, dockerPushImageTags []string) {
-
-	// pull docker image
-	tools.ExecCmd("docker", "pull", imagePull)
+func dockerReleasePullPush(ctx context.Context, skip bool, imagePull string, dockerPushImage string, dockerPushImageTags []string, login func() error) error {
 
-	for _, imagePush := range dockerPushImageTags {
-		log.Info("Re-tag docker image", log.Fields{"build": imagePull, "release": imagePush})
-		tools.ExecCmd("docker", "tag", imagePull, imagePush)
+	// pull and re-tag docker image
+	log.Info("Re-tag docker image", log.Fields{"build": imagePull, "release": dockerPushImageTags})
+	if err := samo.Run(ctx, samo.DockerRetag(imagePull, dockerPushImageTags)...); err != nil {
+		return err
 	}
 
 	if skip {
 		log.Info("Skip docker push for docker release image", log.Fields{"image": dockerPushImage, "tags": dockerPushImageTags})
 	} else {
-		dockerImagePush(dockerPushImage, dockerPushImageTags, skip)
+		if err := login(); err != nil {
+			return err
+		}
+		if err := dockerImagePush(ctx, dockerPushImage, dockerPushImageTags, skip); err != nil {
+			return err
+		}
 		log.Info("Release docker image done!", log.F("image", dockerPushImage))
 	}
+	return nil
 }
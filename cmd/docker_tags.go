@@ -2,8 +2,10 @@ package cmd
 
 import (
 	"fmt"
-	"github.com/spf13/cobra"
 	"strings"
+
+	"github.com/lorislab/samo/pkg/samo"
+	"github.com/spf13/cobra"
 )
 
 func createDockerTagsCmd() *cobra.Command {
@@ -11,12 +13,16 @@ func createDockerTagsCmd() *cobra.Command {
 		Use:   "tags",
 		Short: "Create list of docker image tags",
 		Long:  `Create list of docker image tags`,
-		Run: func(cmd *cobra.Command, args []string) {
-
+		RunE: func(cmd *cobra.Command, args []string) error {
 			flags := dockerFlags{}
-			readOptions(&flags)
-			project := loadProject(flags.Project)
-			dockerTagsCmd(project, flags)
+			if err := readOptions(&flags); err != nil {
+				return err
+			}
+			project, err := loadProject(cmd.Context(), flags.Project)
+			if err != nil {
+				return err
+			}
+			return dockerTagsCmd(project, flags)
 		},
 		TraverseChildren: true,
 	}
@@ -24,8 +30,11 @@ func createDockerTagsCmd() *cobra.Command {
 	return cmd
 }
 
-func dockerTagsCmd(project *Project, flags dockerFlags) {
-	var dockerImage string
-	tags := dockerTags(dockerImage, project, flags.TagListTemplate)
+func dockerTagsCmd(project *Project, flags dockerFlags) error {
+	tags, err := samo.DockerTags(project, "", flags.TagListTemplate)
+	if err != nil {
+		return err
+	}
 	fmt.Printf("%s\n", strings.Join(tags, ","))
+	return nil
 }
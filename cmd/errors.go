@@ -0,0 +1,34 @@
+package cmd
+
+import (
+	"errors"
+
+	"github.com/lorislab/samo/tools"
+)
+
+// Process exit codes
+const (
+	exitCodeError        = 1
+	exitCodeUserError    = 2
+	exitCodeToolFailure  = 3
+	exitCodePrecondition = 4
+)
+
+// commandStarted the command passed the flags validation
+var commandStarted = false
+
+// exitCode map the error to the process exit code
+func exitCode(err error) int {
+	switch {
+	case errors.Is(err, tools.ErrCommandFailed):
+		return exitCodeToolFailure
+	case errors.Is(err, tools.ErrPrecondition), errors.Is(err, tools.ErrNoTag):
+		return exitCodePrecondition
+	case errors.Is(err, tools.ErrInvalidInput), errors.Is(err, tools.ErrInvalidVersion):
+		return exitCodeUserError
+	case !commandStarted:
+		// cobra usage errors: unknown command, flag or argument
+		return exitCodeUserError
+	}
+	return exitCodeError
+}
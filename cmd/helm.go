@@ -1,7 +1,8 @@
 package cmd
 
 import (
-	"helm.sh/helm/v3/pkg/chart"
+	"context"
+	"fmt"
 	"os"
 	"path/filepath"
 	"regexp"
@@ -9,26 +10,35 @@ import (
 	"time"
 
 	"github.com/lorislab/samo/log"
+	"github.com/lorislab/samo/pkg/samo"
 	"github.com/lorislab/samo/tools"
 	"github.com/spf13/cobra"
 	"gopkg.in/yaml.v2"
+	"helm.sh/helm/v3/pkg/chart"
 )
 
 var yamlKeyRegex = regexp.MustCompile(`^"|['"](\w+(?:\.\w+)*)['"]|(\w+)`)
 
 type helmFlags struct {
-	Project       projectFlags `mapstructure:",squash"`
-	Repo          string       `mapstructure:"helm-repo"`
-	RepoUsername  string       `mapstructure:"helm-repo-username" yaml:"-"`
-	RepoPassword  string       `mapstructure:"helm-repo-password" yaml:"-"`
-	RepositoryURL string       `mapstructure:"helm-repo-url"`
-	Clean         bool         `mapstructure:"helm-clean"`
-	PushURL       string       `mapstructure:"helm-push-url"`
-	PushType      string       `mapstructure:"helm-push-type"`
-	Dir           string       `mapstructure:"helm-dir"`
-	Registry      string       `mapstructure:"helm-registry"`
-	AbsoluteDir   bool         `mapstructure:"helm-absolute-dir"`
-	AddRepoDeps   bool         `mapstructure:"helm-add-repo-deps"`
+	Project       projectFlags          `mapstructure:",squash"`
+	Repo          string                `mapstructure:"helm-repo"`
+	RepoUsername  string                `mapstructure:"helm-repo-username" yaml:"-"`
+	RepoPassword  string                `mapstructure:"helm-repo-password" yaml:"-"`
+	RepositoryURL string                `mapstructure:"helm-repo-url"`
+	Clean         bool                  `mapstructure:"helm-clean"`
+	PushURL       string                `mapstructure:"helm-push-url"`
+	PushType      string                `mapstructure:"helm-push-type"`
+	Dir           string                `mapstructure:"helm-dir"`
+	Registry      string                `mapstructure:"helm-registry"`
+	AbsoluteDir   bool                  `mapstructure:"helm-absolute-dir"`
+	AddRepoDeps   bool                  `mapstructure:"helm-add-repo-deps"`
+	Sign          bool                  `mapstructure:"helm-sign"`
+	SignKey       string                `mapstructure:"helm-sign-key"`
+	SignKeyring   string                `mapstructure:"helm-sign-keyring"`
+	SignPassFile  string                `mapstructure:"helm-sign-passphrase-file"`
+	Verify        bool                  `mapstructure:"helm-verify"`
+	VerifyKeyring string                `mapstructure:"helm-verify-keyring"`
+	RegistryAuth  helmRegistryAuthFlags `mapstructure:",squash"`
 }
 
 func createHelmCmd() *cobra.Command {
@@ -48,8 +58,15 @@ func createHelmCmd() *cobra.Command {
 	addStringFlag(cmd, "helm-push-url", "", "", "helm repository push URL [deprecated]")
 	addStringFlag(cmd, "helm-push-type", "", "harbor", "helm repository push type. Values: upload,harbor [deprecated]")
 	addStringFlag(cmd, "helm-registry", "", "", "helm OCI registry")
+	addRegistryAuthFlags(cmd, "helm-registry", "SAMO_HELM_REGISTRY", "helm OCI registry")
 	addBoolFlag(cmd, "helm-absolute-dir", "", false, "helm chart absolute directory (skip add project name in path)")
 	addBoolFlag(cmd, "helm-add-repo-deps", "", false, "add https repositories from dependencies")
+	addBoolFlag(cmd, "helm-sign", "", false, "sign the helm chart package with a PGP key and create the provenance file")
+	addStringFlag(cmd, "helm-sign-key", "", "", "name of the key used for the helm chart signing")
+	addStringFlag(cmd, "helm-sign-keyring", "", "", "location of the secret keyring used for the helm chart signing. Default helm keyring.")
+	addStringFlag(cmd, "helm-sign-passphrase-file", "", "", "location of the file which contains the passphrase for the signing key")
+	addBoolFlag(cmd, "helm-verify", "", false, "verify the provenance file of the helm chart before the release")
+	addStringFlag(cmd, "helm-verify-keyring", "", "", "location of the public keyring used for the verification. Default helm keyring.")
 
 	addChildCmd(cmd, createHelmBuildCmd())
 	addChildCmd(cmd, createHelmPushCmd())
@@ -57,46 +74,68 @@ func createHelmCmd() *cobra.Command {
 	addChildCmd(cmd, createHelmDepsValidateCmd())
 	addChildCmd(cmd, createHelmDepsUpdateCmd())
 	addChildCmd(cmd, createHelmLockUpdateCmd())
+	addChildCmd(cmd, createHelmSchemaCmd())
+	addChildCmd(cmd, createHelmDocsCmd())
 	return cmd
 }
 
-func helmPackage(project *Project, flags helmFlags) {
-	tools.ExecCmd("helm", "package", helmDir(project, flags))
+func helmPackage(ctx context.Context, project *Project, flags helmFlags) error {
+	return helmPackageDir(ctx, helmDir(project, flags), flags)
+}
+
+// helmPackageDir package the chart directory, sign the package if it is enabled
+func helmPackageDir(ctx context.Context, dir string, flags helmFlags) error {
+	var sign *samo.HelmSign
+	if flags.Sign {
+		if len(flags.SignKey) == 0 {
+			return fmt.Errorf("%w: flag --helm-sign-key is mandatory for the helm chart signing", tools.ErrInvalidInput)
+		}
+		sign = &samo.HelmSign{Key: flags.SignKey, Keyring: flags.SignKeyring, PassphraseFile: flags.SignPassFile}
+	}
+	command, err := samo.HelmPackage(dir, sign)
+	if err != nil {
+		return err
+	}
+	return samo.Run(ctx, command)
 }
 
-func helmClean(flags helmFlags) {
+func helmClean(flags helmFlags) error {
 	// clean output directory
 	if !flags.Clean {
 		log.Debug("Helm clean disabled.")
-		return
+		return nil
 	}
 	if _, err := os.Stat(flags.Dir); !os.IsNotExist(err) {
 		log.Debug("Clean directory", log.F("dir", flags.Dir))
 		err := os.RemoveAll(flags.Dir)
 		if err != nil {
-			log.Panic("error delete directory", log.F("output", flags.Dir).E(err))
+			return fmt.Errorf("error delete directory %s: %w", flags.Dir, err)
 		}
 	}
+	return nil
 }
 
-func helmAddRepoDeps(h *chart.Chart) {
+func helmAddRepoDeps(ctx context.Context, h *chart.Chart) error {
 
 	index := false
 	for _, d := range h.Metadata.Dependencies {
 		repo := d.Repository
 		if len(repo) > 0 && strings.HasPrefix(repo, "https://") {
-			helmAddRepository(repo)
+			if err := helmAddRepository(ctx, repo); err != nil {
+				return err
+			}
 			index = true
 		}
 	}
 
 	// update index of the added repository
 	if index {
-		tools.ExecCmd("helm", "repo", "update")
+		return samo.Run(ctx, samo.RetryCmd("helm", "repo", "update"))
 	}
+	return nil
 }
 
-func helmAddRepository(repo string) {
+func helmAddRepository(ctx context.Context, repo string) error {
 
 	// create name from URL
 	name := strings.TrimPrefix(repo, "https://")
@@ -110,26 +149,15 @@ func helmAddRepository(repo string) {
 	var command []string
 	command = append(command, "repo", "add")
 	command = append(command, name, repo)
-	tools.ExecCmd("helm", command...)
+	return samo.Run(ctx, samo.Cmd("helm", command...))
 }
 
-var envRegexp = createRegexp()
-
-// initialize yaml file configuration source
-func createRegexp() *regexp.Regexp {
-
-	// initialize the regex for EnvConfigSource
-	tmp, err := regexp.Compile("[^a-zA-Z0-9]+")
-	if err != nil {
-		panic(err)
-	}
-	return tmp
-}
+var envRegexp = regexp.MustCompile("[^a-zA-Z0-9]+")
 
 // deprecated
-func helmAddRepo(flags helmFlags) {
+func helmAddRepo(ctx context.Context, flags helmFlags) error {
 	if len(flags.Repo) == 0 {
-		return
+		return nil
 	}
 
 	// add repository
@@ -145,48 +173,54 @@ func helmAddRepo(flags helmFlags) {
 		exclude = append(exclude, len(command)-1)
 	}
 	command = append(command, flags.Repo, flags.RepositoryURL)
-	tools.ExecCmdAdv(exclude, "helm", command...)
+	if err := samo.Run(ctx, samo.Command{Name: "helm", Args: command, Mask: exclude}); err != nil {
+		return err
+	}
 
 	// update index of the added repository
-	tools.ExecCmd("helm", "repo", "update")
+	return samo.Run(ctx, samo.RetryCmd("helm", "repo", "update"))
 }
 
-func helmPush(version string, project *Project, flags helmFlags) {
+func helmPush(ctx context.Context, version string, project *Project, flags helmFlags) error {
 
-	filename := project.Name() + `-` + version + `.tgz`
+	filename := samo.HelmPackageFile(project.Name(), version)
 	if !tools.Exists(filename) {
-		log.Fatal("Helm package file does not exists!", log.F("helm-file", filename))
+		return fmt.Errorf("%w: helm package file %s does not exists", tools.ErrPrecondition, filename)
 	}
 
 	// upload helm chart
 	if flags.Project.SkipPush {
 		log.Info("Skip push release version of the helm chart", log.Fields{"push-registry": flags.Registry, "version": version, "push-url": flags.PushURL})
-		return
+		return nil
+	}
+
+	prov := filename + ".prov"
+	if !tools.Exists(prov) {
+		prov = ""
+	} else {
+		log.Info("Push helm chart provenance file", log.F("prov", prov))
 	}
 
 	// push helm repository
 	if len(flags.Registry) == 0 {
-		helmPushRepository(filename, version, project, flags)
-		return
+		return helmPushRepository(ctx, filename, prov, version, flags)
 	}
 
-	var command []string
-	var exclude []int
-
-	command = append(command, "push")
-	command = append(command, filename)
-	command = append(command, flags.Registry)
-	tools.ExecCmdAdv(exclude, "helm", command...)
+	if err := registryLogin(ctx, registryHelm, helmRegistryAuth(flags.Registry, flags.RegistryAuth)); err != nil {
+		return err
+	}
+	// helm push the provenance file next to the package automatically
+	return samo.Run(ctx, samo.HelmPush(filename, flags.Registry))
 }
 
 // deprecated
-func helmPushRepository(filename string, version string, project *Project, flags helmFlags) {
+func helmPushRepository(ctx context.Context, filename, prov, version string, flags helmFlags) error {
 
 	var command []string
 	var exclude []int
 
 	if len(flags.PushURL) == 0 {
-		log.Fatal("Flag --helm-push-url is mandatory!", log.Fields{"--helm-push-url": flags.PushURL, "version": version})
+		return fmt.Errorf("%w: flag --helm-push-url is mandatory (version: %s)", tools.ErrInvalidInput, version)
 	}
 
 	command = append(command, "-fis", "--show-error")
@@ -197,34 +231,73 @@ func helmPushRepository(filename string, version string, project *Project, flags
 
 	switch flags.PushType {
 	case "upload":
-		command = append(command, flags.PushURL, "--upload-file", filename)
+		chartURL, provURL := helmUploadURLs(flags.PushURL, filename)
+		if err := samo.Run(ctx, curlCmd(exclude, append(command, chartURL, "--upload-file", filename)...)); err != nil {
+			return err
+		}
+		if len(prov) > 0 {
+			return samo.Run(ctx, curlCmd(exclude, append(command, provURL, "--upload-file", prov)...))
+		}
+		return nil
 	case "harbor":
-		command = append(command, "-F", `chart=@`+filename, flags.PushURL)
-	default:
-		log.Fatal("Not supported helm push type", log.F("push-type", flags.PushType))
+		command = append(command, "-F", `chart=@`+filename)
+		if len(prov) > 0 {
+			command = append(command, "-F", `prov=@`+prov)
+		}
+		return samo.Run(ctx, curlCmd(exclude, append(command, flags.PushURL)...))
 	}
+	return fmt.Errorf("%w: not supported helm push type %s", tools.ErrInvalidInput, flags.PushType)
+}
+
+// helmUploadURLs upload URLs of the chart package and the provenance file. The push URL with the trailing '/'
+// is the directory of the files, otherwise it is the URL of the package and the provenance file is next to it.
+func helmUploadURLs(pushURL, filename string) (string, string) {
+	if strings.HasSuffix(pushURL, "/") {
+		name := filepath.Base(filename)
+		return pushURL + name, pushURL + name + ".prov"
+	}
+	return pushURL, pushURL + ".prov"
+}
 
-	tools.ExecCmdAdv(exclude, "curl", command...)
+// curlCmd curl upload command with the masked arguments, the upload is retried
+func curlCmd(exclude []int, args ...string) samo.Command {
+	c := samo.RetryCmd("curl", args...)
+	c.Mask = exclude
+	return c
 }
 
 // update helm version, app-version, annotations/labels in Chart.yaml
-func updateHelmValues(project *Project, flags helmFlags, valuesTemplate string) {
+func updateHelmValues(project *Project, flags helmFlags, valuesTemplate string) error {
+	return updateHelmValuesDir(helmDir(project, flags), project, valuesTemplate)
+}
+
+// update values.yaml in the chart directory, template data could be the project or project wrapper
+func updateHelmValuesDir(dir string, project interface{}, valuesTemplate string) error {
 	if len(valuesTemplate) < 1 {
-		return
+		return nil
 	}
 	data := map[string]string{}
-	t := templateToMap(valuesTemplate, project)
+	t, err := templateToMap(valuesTemplate, project)
+	if err != nil {
+		return err
+	}
 	for k, v := range t {
 		data[k] = v
 	}
 	if len(data) > 0 {
-		file := filepath.FromSlash(helmDir(project, flags) + "/values.yaml")
-		replaceValueInYaml(file, data)
+		file := filepath.FromSlash(dir + "/values.yaml")
+		return replaceValueInYaml(file, data)
 	}
+	return nil
 }
 
 // update helm version, app version, annotations/labels in Chart.yaml
-func updateHelmChart(project *Project, flags helmFlags, chartTemplate string) {
+func updateHelmChart(project *Project, flags helmFlags, chartTemplate string) error {
+	return updateHelmChartDir(helmDir(project, flags), project, project, flags, chartTemplate)
+}
+
+// update Chart.yaml in the chart directory, template data could be the project or project wrapper
+func updateHelmChartDir(dir string, project *Project, templateData interface{}, flags helmFlags, chartTemplate string) error {
 	data := map[string]string{}
 
 	if !flags.Project.SkipLabels {
@@ -233,141 +306,169 @@ func updateHelmChart(project *Project, flags helmFlags, chartTemplate string) {
 		data[`annotations."samo.project.created"`] = time.Now().Format(time.RFC3339)
 	}
 	if len(flags.Project.LabelTemplate) > 0 {
-		t := templateToMap(flags.Project.LabelTemplate, project)
+		t, err := templateToMap(flags.Project.LabelTemplate, templateData)
+		if err != nil {
+			return err
+		}
 		for k, v := range t {
 			data[`annotations."`+k+`"`] = v
 		}
 	}
 	if len(chartTemplate) > 0 {
-		t := templateToMap(chartTemplate, project)
+		t, err := templateToMap(chartTemplate, templateData)
+		if err != nil {
+			return err
+		}
 		for k, v := range t {
 			data[k] = v
 		}
 	}
 	if len(data) < 1 {
-		return
+		return nil
 	}
-	file := filepath.FromSlash(helmDir(project, flags) + "/Chart.yaml")
-	replaceValueInYaml(file, data)
+	file := filepath.FromSlash(dir + "/Chart.yaml")
+	return replaceValueInYaml(file, data)
 }
 
 func helmDir(project *Project, flags helmFlags) string {
 	if flags.AbsoluteDir {
 		return flags.Dir
 	}
-	return flags.Dir + "/" + project.name
+	return flags.Dir + "/" + project.Name()
 }
 
-func templateToMap(template string, data interface{}) map[string]string {
+func templateToMap(template string, data interface{}) (map[string]string, error) {
 	r := map[string]string{}
-	labelTemplate := tools.Template(data, template)
+	labelTemplate, err := tools.Template(data, template)
+	if err != nil {
+		return nil, err
+	}
 	labels := strings.Split(labelTemplate, ",")
 	for _, label := range labels {
 		v := strings.SplitN(label, "=", 2)
+		if len(v) < 2 {
+			return nil, fmt.Errorf("%w: invalid key value item '%s' in the template list", tools.ErrInvalidInput, label)
+		}
 		r[v[0]] = v[1]
 	}
-	return r
+	return r, nil
 }
 
-func saveChart(project *Project, flags helmFlags, c *chart.Chart) {
+func saveChart(project *Project, flags helmFlags, c *chart.Chart) error {
 	filename := helmDir(project, flags) + "/Chart.yaml"
-	saveChartFile(filename, c)
+	return saveChartFile(filename, c)
 }
 
-func saveChartFile(filename string, c *chart.Chart) {
+func saveChartFile(filename string, c *chart.Chart) error {
 
 	var fileBytes, err = yaml.Marshal(c.Metadata)
 	if err != nil {
-		log.Fatal("error marshal chart file", log.E(err).F("file", filename))
+		return fmt.Errorf("error marshal chart file %s: %w", filename, err)
 	}
 
 	err = os.WriteFile(filename, fileBytes, 0666)
 	if err != nil {
-		log.Panic("error write chart file", log.E(err).F("file", filename))
+		return fmt.Errorf("error write chart file %s: %w", filename, err)
 	}
 	log.Info("Save chart file", log.F("file", filename))
+	return nil
 }
 
-func loadChart(project *Project, flags helmFlags) *chart.Chart {
+func loadChart(project *Project, flags helmFlags) (*chart.Chart, error) {
 	filename := helmDir(project, flags) + "/Chart.yaml"
 	return loadChartFile(filename)
 }
 
-func loadChartFile(filename string) *chart.Chart {
+func loadChartFile(filename string) (*chart.Chart, error) {
 
 	c := new(chart.Chart)
 	c.Metadata = new(chart.Metadata)
 
 	if !tools.Exists(filename) {
-		log.Fatal("Helm yaml file does not exists!", log.F("file", filename))
+		return nil, fmt.Errorf("%w: helm yaml file %s does not exists", tools.ErrPrecondition, filename)
 	}
 
 	fileBytes, err := os.ReadFile(filename)
 	if err != nil {
-		log.Panic("error read file", log.E(err).F("file", filename))
+		return nil, fmt.Errorf("error read file %s: %w", filename, err)
 	}
 
 	if err := yaml.Unmarshal(fileBytes, c.Metadata); err != nil {
-		log.Panic("error read file", log.E(err).F("file", filename))
+		return nil, fmt.Errorf("error read file %s: %w", filename, err)
 	}
 
 	if c.Metadata.APIVersion == "" {
 		c.Metadata.APIVersion = chart.APIVersionV2
 	}
 
-	return c
+	return c, nil
 }
 
-func replaceValueInYaml(filename string, data map[string]string) {
+func replaceValueInYaml(filename string, data map[string]string) error {
 
 	obj := make(map[interface{}]interface{})
 
 	if !tools.Exists(filename) {
-		log.Fatal("Helm yaml file does not exists!", log.F("file", filename))
+		return fmt.Errorf("%w: helm yaml file %s does not exists", tools.ErrPrecondition, filename)
 	}
 
 	fileBytes, err := os.ReadFile(filename)
 	if err != nil {
-		log.Panic("error read file", log.E(err).F("file", filename))
+		return fmt.Errorf("error read file %s: %w", filename, err)
 	}
 	err = yaml.Unmarshal(fileBytes, &obj)
 	if err != nil {
-		log.Panic("error unmarshal file", log.E(err).F("file", filename))
+		return fmt.Errorf("error unmarshal file %s: %w", filename, err)
 	}
 	for k, v := range data {
-		replace(obj, k, v)
+		if err := replace(obj, k, v); err != nil {
+			return fmt.Errorf("error update file %s: %w", filename, err)
+		}
 	}
 
 	fileBytes, err = yaml.Marshal(&obj)
 	if err != nil {
-		log.Fatal("error marshal file", log.E(err).F("file", filename))
+		return fmt.Errorf("error marshal file %s: %w", filename, err)
 	}
 
 	err = os.WriteFile(filename, fileBytes, 0666)
 	if err != nil {
-		log.Panic("error write file", log.E(err).F("file", filename))
+		return fmt.Errorf("error write file %s: %w", filename, err)
 	}
 	log.Info("Update file", log.F("file", filename))
+	return nil
 }
 
-func replace(obj map[interface{}]interface{}, k string, v string) {
+func replace(obj map[interface{}]interface{}, k string, v string) error {
 	keys := yamlKeyRegex.FindAllString(k, -1)
 
 	var tmp interface{}
 	size := len(keys)
+	if size == 0 {
+		return fmt.Errorf("%w: invalid yaml key '%s'", tools.ErrInvalidInput, k)
+	}
 
 	tmp = obj
 	for i := 0; i < size-1; i++ {
 		key := keys[i]
 		key = strings.TrimSuffix(strings.TrimPrefix(key, `"`), `"`)
-		a := tmp.(map[interface{}]interface{})[key]
+		m, ok := tmp.(map[interface{}]interface{})
+		if !ok {
+			return fmt.Errorf("%w: yaml key '%s' is not a map", tools.ErrInvalidInput, k)
+		}
+		a := m[key]
 		if a == nil {
 			a = map[interface{}]interface{}{}
-			tmp.(map[interface{}]interface{})[key] = a
+			m[key] = a
 		}
 		tmp = a
 	}
 	key := keys[size-1]
 	key = strings.TrimSuffix(strings.TrimPrefix(key, `"`), `"`)
-	tmp.(map[interface{}]interface{})[key] = v
+	m, ok := tmp.(map[interface{}]interface{})
+	if !ok {
+		return fmt.Errorf("%w: yaml key '%s' is not a map", tools.ErrInvalidInput, k)
+	}
+	m[key] = v
+	return nil
 }
@@ -1,21 +1,26 @@
 package cmd
 
 import (
+	"context"
+	"fmt"
 	"os"
 	"strings"
 
 	"github.com/lorislab/samo/log"
+	"github.com/lorislab/samo/pkg/samo"
 	"github.com/lorislab/samo/tools"
 	"github.com/spf13/cobra"
 )
 
 type helmBuildFlags struct {
-	Helm                 helmFlags `mapstructure:",squash"`
-	Source               string    `mapstructure:"helm-source-dir"`
-	DepsCmd              string    `mapstructure:"helm-deps-cmd"`
-	Copy                 bool      `mapstructure:"helm-source-copy"`
-	ChartFilterTemplate  string    `mapstructure:"helm-chart-template-list"`
-	ValuesFilterTemplate string    `mapstructure:"helm-values-template-list"`
+	Helm                 helmFlags     `mapstructure:",squash"`
+	Source               string        `mapstructure:"helm-source-dir"`
+	DepsCmd              string        `mapstructure:"helm-deps-cmd"`
+	Copy                 bool          `mapstructure:"helm-source-copy"`
+	ChartFilterTemplate  string        `mapstructure:"helm-chart-template-list"`
+	ValuesFilterTemplate string        `mapstructure:"helm-values-template-list"`
+	MultiChart           bool          `mapstructure:"helm-multi-chart"`
+	Lint                 helmLintFlags `mapstructure:",squash"`
 }
 
 func createHelmBuildCmd() *cobra.Command {
@@ -23,81 +28,120 @@ func createHelmBuildCmd() *cobra.Command {
 		Use:   "build",
 		Short: "Build helm chart",
 		Long:  `Helm build helm chart`,
-		Run: func(cmd *cobra.Command, args []string) {
+		RunE: func(cmd *cobra.Command, args []string) error {
 			flags := helmBuildFlags{}
-			readOptions(&flags)
-			project := loadProject(flags.Helm.Project)
-			helmBuild(project, flags)
+			if err := readOptions(&flags); err != nil {
+				return err
+			}
+			project, err := loadProject(cmd.Context(), flags.Helm.Project)
+			if err != nil {
+				return err
+			}
+			return helmBuild(cmd.Context(), project, flags)
 		},
 		TraverseChildren: true,
 	}
 
 	addStringFlag(cmd, "helm-source-dir", "", "", "project helm chart source directory")
 	addStringFlag(cmd, "helm-deps-cmd", "", "build", "helm dependency command.")
-	addBoolFlag(cmd, "helm-source-copy", "", false, "copy helm source to helm directory")
+	addBoolFlag(cmd, "helm-source-copy", "", false, "copy helm source to helm directory. The multi chart build always builds the copy in the empty helm directory, see --helm-clean.")
 	addStringFlag(cmd, "helm-chart-template-list", "", "version={{ .Version }},appVersion={{ .Version }},name={{ .Name }}", `list of key value to be replaced in the Chart.yaml
 	Values: `+templateValues+`
 	Example: version={{ .Release }},appVersion={{ .Release }}`)
 	addStringFlag(cmd, "helm-values-template-list", "", "", `list of key value to be replaced in the values.yaml Example: image.tag={{ .Version }}
 	Values: `+templateValues)
+	addBoolFlag(cmd, "helm-multi-chart", "", false, `build all charts found in the helm source directory.
+	Local 'file://' dependencies get the computed version and the charts are packaged in dependency order`)
+	addHelmLintFlags(cmd)
 
 	return cmd
 }
 
-func helmBuild(project *Project, flags helmBuildFlags) {
+func helmBuild(ctx context.Context, project *Project, flags helmBuildFlags) error {
 
 	// clean helm dir
-	helmClean(flags.Helm)
+	if err := helmClean(flags.Helm); err != nil {
+		return err
+	}
 	// add and update custom helm repo
-	helmAddRepo(flags.Helm)
+	if err := helmAddRepo(ctx, flags.Helm); err != nil {
+		return err
+	}
+
+	// build umbrella chart and local sub-charts
+	if flags.MultiChart {
+		return helmBuildCharts(ctx, project, flags)
+	}
+
 	// add repo from chart dependencies
 	if flags.Helm.AddRepoDeps {
-		chart := loadChart(project, flags.Helm)
-		helmAddRepoDeps(chart)
+		chart, err := loadChart(project, flags.Helm)
+		if err != nil {
+			return err
+		}
+		if err := helmAddRepoDeps(ctx, chart); err != nil {
+			return err
+		}
 	}
 
 	// filter resources to output dir
-	buildHelmChart(flags, project)
+	if err := buildHelmChart(flags, project); err != nil {
+		return err
+	}
 
-	updateHelmChart(project, flags.Helm, flags.ChartFilterTemplate)
-	updateHelmValues(project, flags.Helm, flags.ValuesFilterTemplate)
+	if err := updateHelmChart(project, flags.Helm, flags.ChartFilterTemplate); err != nil {
+		return err
+	}
+	if err := updateHelmValues(project, flags.Helm, flags.ValuesFilterTemplate); err != nil {
+		return err
+	}
 
 	// update helm dependencies
-	tools.ExecCmd("helm", "dependency", flags.DepsCmd, helmDir(project, flags.Helm))
+	if err := samo.Run(ctx, samo.HelmDependency(flags.DepsCmd, helmDir(project, flags.Helm))); err != nil {
+		return err
+	}
+
+	// lint and validate helm chart
+	if err := helmLint(helmDir(project, flags.Helm), project.Name(), flags); err != nil {
+		return err
+	}
 
 	// package helm chart
-	helmPackage(project, flags.Helm)
+	return helmPackage(ctx, project, flags.Helm)
 }
 
 // Filter filter helm resources
-func buildHelmChart(flags helmBuildFlags, pro *Project) {
+func buildHelmChart(flags helmBuildFlags, pro *Project) error {
 	if !flags.Copy {
 		log.Debug("helm chart copy is disabled")
-		return
+		return nil
 	}
 	if len(flags.Source) < 1 {
 		log.Debug("no helm chart source directory configured")
-		return
+		return nil
 	}
 	// get all files from the input directory
 	if _, err := os.Stat(flags.Source); os.IsNotExist(err) {
-		log.Fatal("source helm directory does not exists!", log.F("source", flags.Source))
+		return fmt.Errorf("%w: source helm directory %s does not exists", tools.ErrPrecondition, flags.Source)
 	}
 
 	paths, err := tools.GetAllFilePathsInDirectory(flags.Source)
 	if err != nil {
-		log.Fatal("error read helm source directory", log.F("source", flags.Source).E(err))
+		return fmt.Errorf("error read helm source directory %s: %w", flags.Source, err)
 	}
 
 	for _, path := range paths {
 		// load file
 		result, err := os.ReadFile(path)
 		if err != nil {
-			log.Fatal("error read file", log.F("file", path).E(err))
+			return fmt.Errorf("error read file %s: %w", path, err)
 		}
 		// write result to output directory
-		out := strings.ReplaceAll(path, flags.Source, flags.Helm.Dir+"/"+pro.name)
-		tools.WriteBytesToFile(out, result)
+		out := strings.ReplaceAll(path, flags.Source, flags.Helm.Dir+"/"+pro.Name())
+		if err := tools.WriteBytesToFile(out, result); err != nil {
+			return err
+		}
 		log.Debug("Copy file", log.F("out", out).F("in", path))
 	}
+	return nil
 }
@@ -0,0 +1,299 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/lorislab/samo/log"
+	"github.com/lorislab/samo/pkg/samo"
+	"github.com/lorislab/samo/tools"
+	"gopkg.in/yaml.v2"
+	"helm.sh/helm/v3/pkg/chartutil"
+)
+
+const helmLocalRepositoryPrefix = "file://"
+
+// helmLocalChart local chart found in the helm source directory
+type helmLocalChart struct {
+	Name string
+	Dir  string
+	Deps []string
+}
+
+// helmChartProject project wrapper for the chart templates with the name of the local chart
+type helmChartProject struct {
+	*Project
+	name string
+}
+
+// Name local chart name
+func (p helmChartProject) Name() string {
+	return p.name
+}
+
+// discoverHelmCharts find all Chart.yaml files in the directory and resolve the local 'file://' dependencies
+func discoverHelmCharts(dir string) (map[string]*helmLocalChart, error) {
+	charts := map[string]*helmLocalChart{}
+	dirs := map[string]string{}
+
+	err := filepath.Walk(dir, func(path string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+		// the charts directory of the chart has the packaged or vendored subcharts
+		if info.IsDir() && info.Name() == "charts" && tools.Exists(filepath.Join(filepath.Dir(path), "Chart.yaml")) {
+			return filepath.SkipDir
+		}
+		if info.IsDir() || info.Name() != "Chart.yaml" {
+			return nil
+		}
+		metadata, err := chartutil.LoadChartfile(path)
+		if err != nil {
+			return fmt.Errorf("error read chart file %s: %w", path, err)
+		}
+		chartDir := filepath.Clean(filepath.Dir(path))
+		if c, exists := charts[metadata.Name]; exists {
+			return fmt.Errorf("duplicate chart name %s in %s and %s", metadata.Name, c.Dir, chartDir)
+		}
+		charts[metadata.Name] = &helmLocalChart{Name: metadata.Name, Dir: chartDir}
+		dirs[chartDir] = metadata.Name
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	for _, c := range charts {
+		metadata, err := chartutil.LoadChartfile(filepath.Join(c.Dir, "Chart.yaml"))
+		if err != nil {
+			return nil, err
+		}
+		for _, d := range metadata.Dependencies {
+			if !strings.HasPrefix(d.Repository, helmLocalRepositoryPrefix) {
+				continue
+			}
+			depDir := filepath.Clean(filepath.Join(c.Dir, strings.TrimPrefix(d.Repository, helmLocalRepositoryPrefix)))
+			name, exists := dirs[depDir]
+			if !exists {
+				return nil, fmt.Errorf("missing local dependency %s (%s) of the chart %s", d.Name, d.Repository, c.Name)
+			}
+			c.Deps = append(c.Deps, name)
+		}
+	}
+	return charts, nil
+}
+
+// sortHelmCharts sort the charts in topological order, dependencies first
+func sortHelmCharts(charts map[string]*helmLocalChart) ([]*helmLocalChart, error) {
+	names := make([]string, 0, len(charts))
+	for name := range charts {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	const (
+		visiting = 1
+		done     = 2
+	)
+	state := map[string]int{}
+	var result []*helmLocalChart
+	var path []string
+
+	var visit func(name string) error
+	visit = func(name string) error {
+		switch state[name] {
+		case done:
+			return nil
+		case visiting:
+			start := 0
+			for i, p := range path {
+				if p == name {
+					start = i
+				}
+			}
+			return fmt.Errorf("cycle in local chart dependencies: %s", strings.Join(append(path[start:], name), " -> "))
+		}
+		state[name] = visiting
+		path = append(path, name)
+		deps := append([]string{}, charts[name].Deps...)
+		sort.Strings(deps)
+		for _, d := range deps {
+			if err := visit(d); err != nil {
+				return err
+			}
+		}
+		path = path[:len(path)-1]
+		state[name] = done
+		result = append(result, charts[name])
+		return nil
+	}
+
+	for _, name := range names {
+		if err := visit(name); err != nil {
+			return nil, err
+		}
+	}
+	return result, nil
+}
+
+// helmBuildCharts build all local charts of the source directory in topological order
+func helmBuildCharts(ctx context.Context, project *Project, flags helmBuildFlags) error {
+	if len(flags.Source) < 1 {
+		return fmt.Errorf("%w: flag --helm-source-dir is mandatory for the multi chart build", tools.ErrInvalidInput)
+	}
+	if _, err := os.Stat(flags.Source); os.IsNotExist(err) {
+		return fmt.Errorf("%w: source helm directory %s does not exists", tools.ErrPrecondition, flags.Source)
+	}
+
+	// build the copy of the source directory in the empty helm directory, the source files are not changed
+	// and the copy keeps the relative 'file://' paths. The helm directory is cleaned only with --helm-clean.
+	dir := flags.Helm.Dir
+	source, err := filepath.Abs(flags.Source)
+	if err != nil {
+		return fmt.Errorf("error resolve helm source directory %s: %w", flags.Source, err)
+	}
+	target, err := filepath.Abs(dir)
+	if err != nil {
+		return fmt.Errorf("error resolve helm directory %s: %w", dir, err)
+	}
+	if rel, err := filepath.Rel(target, source); err == nil && (rel == "." || !strings.HasPrefix(rel, "..")) {
+		return fmt.Errorf("%w: helm source directory %s must not be in the helm directory %s", tools.ErrInvalidInput, flags.Source, dir)
+	}
+	if entries, err := os.ReadDir(dir); err == nil && len(entries) > 0 {
+		return fmt.Errorf("%w: helm directory %s is not empty, use --helm-clean to clean the directory before the multi chart build", tools.ErrPrecondition, dir)
+	}
+	if err := copyHelmSource(flags.Source, dir); err != nil {
+		return err
+	}
+
+	charts, err := discoverHelmCharts(dir)
+	if err != nil {
+		return fmt.Errorf("%w: error discover helm charts in %s: %v", tools.ErrPrecondition, dir, err)
+	}
+	ordered, err := sortHelmCharts(charts)
+	if err != nil {
+		return fmt.Errorf("%w: error resolve helm charts build order in %s: %v", tools.ErrPrecondition, dir, err)
+	}
+
+	var order []string
+	for _, c := range ordered {
+		order = append(order, c.Name)
+	}
+	log.Info("Build helm charts", log.F("charts", order))
+
+	versions := map[string]string{}
+	for _, c := range ordered {
+		data := helmChartProject{Project: project, name: c.Name}
+		chartFile := filepath.Join(c.Dir, "Chart.yaml")
+
+		if err := updateHelmChartDir(c.Dir, project, data, flags.Helm, flags.ChartFilterTemplate); err != nil {
+			return err
+		}
+		if tools.Exists(filepath.Join(c.Dir, "values.yaml")) {
+			if err := updateHelmValuesDir(c.Dir, data, flags.ValuesFilterTemplate); err != nil {
+				return err
+			}
+		}
+		if err := updateHelmLocalDependencies(chartFile, versions); err != nil {
+			return err
+		}
+
+		metadata, err := chartutil.LoadChartfile(chartFile)
+		if err != nil {
+			return fmt.Errorf("error read chart file %s: %w", chartFile, err)
+		}
+		versions[c.Name] = metadata.Version
+
+		if flags.Helm.AddRepoDeps {
+			h, err := loadChartFile(chartFile)
+			if err != nil {
+				return err
+			}
+			if err := helmAddRepoDeps(ctx, h); err != nil {
+				return err
+			}
+		}
+		if err := samo.Run(ctx, samo.HelmDependency(flags.DepsCmd, c.Dir)); err != nil {
+			return err
+		}
+		if err := helmLint(c.Dir, c.Name, flags); err != nil {
+			return err
+		}
+		if err := helmPackageDir(ctx, c.Dir, flags.Helm); err != nil {
+			return err
+		}
+		log.Info("Helm chart done!", log.F("chart", c.Name).F("version", metadata.Version))
+	}
+	return nil
+}
+
+// updateHelmLocalDependencies set the version of the local 'file://' dependencies to the computed chart version
+func updateHelmLocalDependencies(filename string, versions map[string]string) error {
+	obj := make(map[interface{}]interface{})
+	fileBytes, err := os.ReadFile(filename)
+	if err != nil {
+		return fmt.Errorf("error read file %s: %w", filename, err)
+	}
+	if err := yaml.Unmarshal(fileBytes, &obj); err != nil {
+		return fmt.Errorf("error unmarshal file %s: %w", filename, err)
+	}
+
+	deps, _ := obj["dependencies"].([]interface{})
+	update := false
+	for _, item := range deps {
+		d, ok := item.(map[interface{}]interface{})
+		if !ok {
+			continue
+		}
+		repo, _ := d["repository"].(string)
+		name, _ := d["name"].(string)
+		if !strings.HasPrefix(repo, helmLocalRepositoryPrefix) {
+			continue
+		}
+		if version, exists := versions[name]; exists {
+			log.Debug("Update local dependency", log.F("file", filename).F("name", name).F("version", version))
+			d["version"] = version
+			update = true
+		}
+	}
+	if !update {
+		return nil
+	}
+
+	fileBytes, err = yaml.Marshal(&obj)
+	if err != nil {
+		return fmt.Errorf("error marshal file %s: %w", filename, err)
+	}
+	if err := os.WriteFile(filename, fileBytes, 0666); err != nil {
+		return fmt.Errorf("error write file %s: %w", filename, err)
+	}
+	return nil
+}
+
+// copyHelmSource copy all files of the source directory to the output directory
+func copyHelmSource(source, output string) error {
+	paths, err := tools.GetAllFilePathsInDirectory(source)
+	if err != nil {
+		return fmt.Errorf("error read helm source directory %s: %w", source, err)
+	}
+	for _, path := range paths {
+		rel, err := filepath.Rel(source, path)
+		if err != nil {
+			return fmt.Errorf("error resolve file path %s: %w", path, err)
+		}
+		result, err := os.ReadFile(path)
+		if err != nil {
+			return fmt.Errorf("error read file %s: %w", path, err)
+		}
+		out := filepath.Join(output, rel)
+		if err := tools.WriteBytesToFile(out, result); err != nil {
+			return err
+		}
+		log.Debug("Copy file", log.F("out", out).F("in", path))
+	}
+	return nil
+}
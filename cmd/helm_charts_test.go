@@ -0,0 +1,64 @@
+package cmd
+
+import (
+	"context"
+	"errors"
+	"os"
+	"testing"
+
+	"github.com/lorislab/samo/pkg/samo"
+	"github.com/lorislab/samo/tools"
+)
+
+func TestDiscoverHelmChartsSkipSubcharts(t *testing.T) {
+	testChdir(t)
+	writeTestFile(t, "helm/app/Chart.yaml", "apiVersion: v2\nname: app\nversion: 0.0.0\ndependencies:\n  - name: lib\n    version: 0.0.0\n    repository: file://../lib\n")
+	writeTestFile(t, "helm/app/charts/vendored/Chart.yaml", "apiVersion: v2\nname: vendored\nversion: 1.0.0\n")
+	writeTestFile(t, "helm/lib/Chart.yaml", "apiVersion: v2\nname: lib\nversion: 0.0.0\n")
+
+	charts, err := discoverHelmCharts("helm")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(charts) != 2 || charts["app"] == nil || charts["lib"] == nil {
+		t.Errorf("charts %v, expected app and lib", charts)
+	}
+}
+
+func TestHelmBuildChartsKeepHelmDir(t *testing.T) {
+	testChdir(t)
+	writeTestFile(t, "src/main/helm/app/Chart.yaml", "apiVersion: v2\nname: app\nversion: 0.0.0\n")
+	writeTestFile(t, "target/helm/notes.txt", "user content\n")
+
+	fake := samo.NewFakeRunner()
+	ctx := samo.WithRunner(context.Background(), fake)
+	project := testProject(t, ctx, testGit{
+		describe: samo.Describe{Tag: "1.0.0", Count: "2", Hash: "abc123"},
+		branch:   "main",
+	})
+
+	flags := helmBuildFlags{}
+	readTestOptions(t, &flags, map[string]interface{}{
+		"helm-source-dir":  "src/main/helm",
+		"helm-multi-chart": true,
+		"skip-samo-labels": true,
+	})
+	err := helmBuild(ctx, project, flags)
+	if !errors.Is(err, tools.ErrPrecondition) {
+		t.Fatalf("multi chart build in the not empty helm directory, error %v", err)
+	}
+	if !tools.Exists("target/helm/notes.txt") {
+		t.Error("multi chart build without --helm-clean removed the helm directory content")
+	}
+	if lines := fake.Lines(); len(lines) > 0 {
+		t.Errorf("failed multi chart build executed the commands %v", lines)
+	}
+
+	readTestOptions(t, &flags, map[string]interface{}{"helm-clean": true})
+	if err := helmBuild(ctx, project, flags); err != nil {
+		t.Fatalf("multi chart build with --helm-clean: %v", err)
+	}
+	if _, err := os.Stat("target/helm/notes.txt"); !os.IsNotExist(err) {
+		t.Error("multi chart build with --helm-clean kept the helm directory content")
+	}
+}
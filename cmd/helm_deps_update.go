@@ -1,6 +1,7 @@
 package cmd
 
 import (
+	"context"
 	"github.com/lorislab/samo/log"
 	"github.com/spf13/cobra"
 	"helm.sh/helm/v3/pkg/chart"
@@ -20,11 +21,16 @@ func createHelmDepsUpdateCmd() *cobra.Command {
 		Use:   "deps-update",
 		Short: "Update or create helm chart dependency",
 		Long:  `Update the Helm chart dependency in Chart.yaml or create a new one if it doesn't exist`,
-		Run: func(cmd *cobra.Command, args []string) {
+		RunE: func(cmd *cobra.Command, args []string) error {
 			flags := helmDepsUpdateFlags{}
-			readOptions(&flags)
-			project := loadProject(flags.Helm.Project)
-			helmDepsUpdate(project, flags)
+			if err := readOptions(&flags); err != nil {
+				return err
+			}
+			project, err := loadProject(cmd.Context(), flags.Helm.Project)
+			if err != nil {
+				return err
+			}
+			return helmDepsUpdate(cmd.Context(), project, flags)
 		},
 		TraverseChildren: true,
 	}
@@ -37,13 +43,18 @@ func createHelmDepsUpdateCmd() *cobra.Command {
 	return cmd
 }
 
-func helmDepsUpdate(project *Project, flags helmDepsUpdateFlags) {
+func helmDepsUpdate(ctx context.Context, project *Project, flags helmDepsUpdateFlags) error {
 
-	c := loadChart(project, flags.Helm)
+	c, err := loadChart(project, flags.Helm)
+	if err != nil {
+		return err
+	}
 
 	// add repo from chart dependencies
 	if flags.Helm.AddRepoDeps {
-		helmAddRepoDeps(c)
+		if err := helmAddRepoDeps(ctx, c); err != nil {
+			return err
+		}
 	}
 
 	notFound := true
@@ -97,9 +108,8 @@ func helmDepsUpdate(project *Project, flags helmDepsUpdateFlags) {
 	}
 
 	if update {
-		saveChart(project, flags.Helm, c)
-	} else {
-		log.Info("No changes found.")
+		return saveChart(project, flags.Helm, c)
 	}
-
+	log.Info("No changes found.")
+	return nil
 }
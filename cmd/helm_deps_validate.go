@@ -1,9 +1,11 @@
 package cmd
 
 import (
+	"context"
 	"fmt"
 	"github.com/Masterminds/semver/v3"
 	"github.com/lorislab/samo/log"
+	"github.com/lorislab/samo/tools"
 	"github.com/spf13/cobra"
 )
 
@@ -17,11 +19,16 @@ func createHelmDepsValidateCmd() *cobra.Command {
 		Use:   "deps-validate",
 		Short: "Validate helm chart dependencies",
 		Long:  `Validate helm chart dependencies`,
-		Run: func(cmd *cobra.Command, args []string) {
+		RunE: func(cmd *cobra.Command, args []string) error {
 			flags := helmDepsValidateFlags{}
-			readOptions(&flags)
-			project := loadProject(flags.Helm.Project)
-			helmDepsValidate(project, flags)
+			if err := readOptions(&flags); err != nil {
+				return err
+			}
+			project, err := loadProject(cmd.Context(), flags.Helm.Project)
+			if err != nil {
+				return err
+			}
+			return helmDepsValidate(cmd.Context(), project, flags)
 		},
 		TraverseChildren: true,
 	}
@@ -30,14 +37,19 @@ func createHelmDepsValidateCmd() *cobra.Command {
 	return cmd
 }
 
-func helmDepsValidate(project *Project, flags helmDepsValidateFlags) {
+func helmDepsValidate(ctx context.Context, project *Project, flags helmDepsValidateFlags) error {
 
-	chart := loadChart(project, flags.Helm)
+	chart, err := loadChart(project, flags.Helm)
+	if err != nil {
+		return err
+	}
 	failed := false
 
 	// add repo from chart dependencies
 	if flags.Helm.AddRepoDeps {
-		helmAddRepoDeps(chart)
+		if err := helmAddRepoDeps(ctx, chart); err != nil {
+			return err
+		}
 	}
 
 	log.Info("Dependencies validation", log.F("validate-type", flags.ValidateType).F("chart", chart.Name()).F("version", chart.Metadata.Version))
@@ -67,8 +79,8 @@ func helmDepsValidate(project *Project, flags helmDepsValidateFlags) {
 	}
 
 	if failed {
-		log.Fatal("One or more dependencies version are not valid! Validation: '" + flags.ValidateType + "'")
-	} else {
-		log.Info("All dependencies version are valid. Validation: '" + flags.ValidateType + "'")
+		return fmt.Errorf("%w: one or more dependencies version are not valid, validation: '%s'", tools.ErrPrecondition, flags.ValidateType)
 	}
+	log.Info("All dependencies version are valid. Validation: '" + flags.ValidateType + "'")
+	return nil
 }
@@ -0,0 +1,192 @@
+package cmd
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/lorislab/samo/log"
+	"github.com/lorislab/samo/tools"
+	"github.com/spf13/cobra"
+	"helm.sh/helm/v3/pkg/chart"
+	"helm.sh/helm/v3/pkg/chartutil"
+)
+
+var defaultHelmDocsTemplate = `# {{ .Chart.Name }}
+
+{{ if .Chart.Description }}{{ .Chart.Description }}
+
+{{ end }}![Version: {{ .Chart.Version }}](https://img.shields.io/badge/Version-{{ .Chart.Version }}-informational?style=flat-square){{ if .Chart.AppVersion }} ![AppVersion: {{ .Chart.AppVersion }}](https://img.shields.io/badge/AppVersion-{{ .Chart.AppVersion }}-informational?style=flat-square){{ end }}
+{{ if .Chart.Home }}
+**Homepage:** <{{ .Chart.Home }}>
+{{ end }}{{ if .Chart.Dependencies }}
+## Requirements
+
+| Repository | Name | Version |
+|------------|------|---------|
+{{ range .Chart.Dependencies }}| {{ .Repository }} | {{ .Name }} | {{ .Version }} |
+{{ end }}{{ end }}
+## Values
+
+| Key | Type | Default | Description |
+|-----|------|---------|-------------|
+{{ range .Parameters }}| {{ .Key }} | {{ .Type }} | {{ .Default }} | {{ .Description }} |
+{{ end }}`
+
+type helmDocsFlags struct {
+	Helm     helmFlags `mapstructure:",squash"`
+	Template string    `mapstructure:"helm-docs-template"`
+	File     string    `mapstructure:"helm-docs-file"`
+	Check    bool      `mapstructure:"helm-docs-check"`
+}
+
+// helmDocsParameter row of the parameter table, the values are escaped for the markdown table
+type helmDocsParameter struct {
+	Key         string
+	Type        string
+	Default     string
+	Description string
+}
+
+// helmDocsData template data of the chart README
+type helmDocsData struct {
+	Project    *Project
+	Chart      *chart.Metadata
+	Parameters []helmDocsParameter
+}
+
+func createHelmDocsCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "docs",
+		Short: "Generate helm chart README",
+		Long: `Generate the README of the helm chart from the Chart.yaml metadata and the values.yaml comments.
+Template values: Project, Chart (Chart.yaml metadata), Parameters (Key,Type,Default,Description), the Default is the markdown code span`,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			flags := helmDocsFlags{}
+			if err := readOptions(&flags); err != nil {
+				return err
+			}
+			project, err := loadProject(cmd.Context(), flags.Helm.Project)
+			if err != nil {
+				return err
+			}
+			return helmDocs(project, flags)
+		},
+		TraverseChildren: true,
+	}
+
+	addStringFlag(cmd, "helm-docs-template", "", "", "path of the README go template file. Default built-in template.")
+	addStringFlag(cmd, "helm-docs-file", "", "README.md", "README file name in the helm chart directory")
+	addBoolFlag(cmd, "helm-docs-check", "", false, "check the README is up to date, do not write the file")
+	return cmd
+}
+
+func helmDocs(project *Project, flags helmDocsFlags) error {
+	dir := helmDir(project, flags.Helm)
+	valuesFile := filepath.Join(dir, "values.yaml")
+	readme := filepath.Join(dir, flags.File)
+
+	template := defaultHelmDocsTemplate
+	if len(flags.Template) > 0 {
+		data, err := os.ReadFile(flags.Template)
+		if err != nil {
+			return fmt.Errorf("%w: error read helm docs template %s: %v", tools.ErrInvalidInput, flags.Template, err)
+		}
+		template = string(data)
+	}
+
+	chartFile := filepath.Join(dir, "Chart.yaml")
+	metadata, err := chartutil.LoadChartfile(chartFile)
+	if err != nil {
+		return fmt.Errorf("%w: error read helm chart file %s: %v", tools.ErrPrecondition, chartFile, err)
+	}
+	data := helmDocsData{
+		Project: project,
+		Chart:   metadata,
+	}
+	if tools.Exists(valuesFile) {
+		values, err := loadHelmValues(valuesFile)
+		if err != nil {
+			return fmt.Errorf("error read helm values file %s: %w", valuesFile, err)
+		}
+		data.Parameters = helmDocsParameters(values)
+	}
+
+	output, err := tools.Template(data, template)
+	if err != nil {
+		return err
+	}
+
+	if flags.Check {
+		current, err := os.ReadFile(readme)
+		if err != nil || string(current) != output {
+			return fmt.Errorf("%w: helm chart README %s is out of date, run 'samo project helm docs' to update it", tools.ErrPrecondition, readme)
+		}
+		log.Info("Helm chart README is up to date.", log.F("file", readme))
+		return nil
+	}
+
+	if err := tools.WriteToFile(readme, output); err != nil {
+		return err
+	}
+	log.Info("Helm chart README created.", log.F("file", readme))
+	return nil
+}
+
+// helmDocsDefault JSON of the default value without the HTML escaping of <, > and &
+func helmDocsDefault(value interface{}) string {
+	var buf bytes.Buffer
+	e := json.NewEncoder(&buf)
+	e.SetEscapeHTML(false)
+	if err := e.Encode(value); err != nil {
+		return fmt.Sprint(value)
+	}
+	return strings.TrimSuffix(buf.String(), "\n")
+}
+
+// markdownCode inline code span of the text, the backtick fence is longer than any backtick run of the text
+func markdownCode(text string) string {
+	longest, run := 0, 0
+	for _, r := range text {
+		if r == '`' {
+			run++
+			if run > longest {
+				longest = run
+			}
+			continue
+		}
+		run = 0
+	}
+	fence := strings.Repeat("`", longest+1)
+	// the space separates the backtick at the start or the end of the text from the fence
+	if strings.HasPrefix(text, "`") || strings.HasSuffix(text, "`") {
+		text = " " + text + " "
+	}
+	return fence + text + fence
+}
+
+// helmDocsParameters flat list of all leaf values
+func helmDocsParameters(value *helmValue) []helmDocsParameter {
+	var result []helmDocsParameter
+	escape := strings.NewReplacer("|", `\|`)
+	for _, child := range value.Children {
+		if len(child.Children) > 0 {
+			result = append(result, helmDocsParameters(child)...)
+			continue
+		}
+		description := child.Description
+		if d, ok := child.Schema["description"].(string); ok {
+			description = d
+		}
+		result = append(result, helmDocsParameter{
+			Key:         escape.Replace(child.Key),
+			Type:        child.Type,
+			Default:     escape.Replace(markdownCode(helmDocsDefault(child.Default))),
+			Description: escape.Replace(description),
+		})
+	}
+	return result
+}
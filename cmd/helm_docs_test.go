@@ -0,0 +1,43 @@
+package cmd
+
+import "testing"
+
+func TestHelmDocsParameters(t *testing.T) {
+	value := &helmValue{Children: []*helmValue{
+		{Key: "url", Type: "string", Default: "https://a.example.com/?a=1&b=<2>"},
+		{Key: "cmd", Type: "string", Default: "echo `date` | tee", Description: "a | b"},
+		{Key: "quote", Type: "string", Default: "`"},
+		{Key: "port", Type: "integer", Default: 8080},
+	}}
+	expected := []helmDocsParameter{
+		{Key: "url", Type: "string", Default: "`\"https://a.example.com/?a=1&b=<2>\"`"},
+		{Key: "cmd", Type: "string", Default: "``\"echo `date` \\| tee\"``", Description: "a \\| b"},
+		{Key: "quote", Type: "string", Default: "``\"`\"``"},
+		{Key: "port", Type: "integer", Default: "`8080`"},
+	}
+	params := helmDocsParameters(value)
+	if len(params) != len(expected) {
+		t.Fatalf("parameters %v, expected %v", params, expected)
+	}
+	for i := range expected {
+		if params[i] != expected[i] {
+			t.Errorf("parameter %+v, expected %+v", params[i], expected[i])
+		}
+	}
+}
+
+func TestMarkdownCode(t *testing.T) {
+	tests := map[string]string{
+		"abc":  "`abc`",
+		"a`b":  "``a`b``",
+		"a``b": "```a``b```",
+		"`a":   "`` `a ``",
+		"a`":   "`` a` ``",
+		"":     "``",
+	}
+	for text, expected := range tests {
+		if code := markdownCode(text); code != expected {
+			t.Errorf("code span of %q: %q, expected %q", text, code, expected)
+		}
+	}
+}
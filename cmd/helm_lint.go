@@ -0,0 +1,324 @@
+package cmd
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/lorislab/samo/log"
+	"github.com/lorislab/samo/tools"
+	"github.com/santhosh-tekuri/jsonschema/v6"
+	"github.com/spf13/cobra"
+	"golang.org/x/text/language"
+	"golang.org/x/text/message"
+	"gopkg.in/yaml.v3"
+	"helm.sh/helm/v3/pkg/chart/loader"
+	"helm.sh/helm/v3/pkg/chartutil"
+	"helm.sh/helm/v3/pkg/cli/values"
+	"helm.sh/helm/v3/pkg/engine"
+	"helm.sh/helm/v3/pkg/getter"
+	"helm.sh/helm/v3/pkg/lint"
+	"helm.sh/helm/v3/pkg/lint/support"
+)
+
+type helmLintFlags struct {
+	Enabled              bool   `mapstructure:"helm-lint"`
+	Strict               bool   `mapstructure:"helm-lint-strict"`
+	Values               string `mapstructure:"helm-lint-values"`
+	Namespace            string `mapstructure:"helm-lint-namespace"`
+	SchemaDir            string `mapstructure:"helm-lint-schema-dir"`
+	IgnoreMissingSchemas bool   `mapstructure:"helm-lint-ignore-missing-schemas"`
+}
+
+// lintProblem single problem found by the lint stage or the config validation
+type lintProblem struct {
+	File    string
+	Line    int
+	Message string
+}
+
+func (p lintProblem) String() string {
+	if p.Line > 0 {
+		return fmt.Sprintf("%s:%d: %s", p.File, p.Line, p.Message)
+	}
+	return p.File + ": " + p.Message
+}
+
+func addHelmLintFlags(cmd *cobra.Command) {
+	addBoolFlag(cmd, "helm-lint", "", false, "lint the helm chart and validate rendered templates before packaging")
+	addBoolFlag(cmd, "helm-lint-strict", "", false, "fail the helm lint on warnings")
+	addStringFlag(cmd, "helm-lint-values", "", "", "comma separated list of additional values files used for the lint and template rendering")
+	addStringFlag(cmd, "helm-lint-namespace", "", "default", "namespace used for the template rendering")
+	addStringFlag(cmd, "helm-lint-schema-dir", "", "", `local directory with kubernetes JSON schemas. Supported layouts:
+	<kind>-<group>-<version>.json, <kind>-<version>.json, <group>/<kind>_<version>.json`)
+	addBoolFlag(cmd, "helm-lint-ignore-missing-schemas", "", false, "skip the validation of resources without a schema in the schema directory")
+}
+
+// helmLint run the helm linter, render the templates and validate them against the kubernetes schemas
+func helmLint(dir, name string, flags helmBuildFlags) error {
+	if !flags.Lint.Enabled {
+		log.Debug("Helm lint disabled.")
+		return nil
+	}
+
+	log.Info("Lint helm chart", log.F("dir", dir))
+
+	vals, err := helmLintValues(flags.Lint.Values)
+	if err != nil {
+		return fmt.Errorf("%w: error read helm lint values files %s: %v", tools.ErrInvalidInput, flags.Lint.Values, err)
+	}
+
+	problems := helmLintChart(dir, vals, flags.Lint)
+
+	manifests, err := helmRenderTemplates(dir, name, vals, flags.Lint.Namespace)
+	if err != nil {
+		problems = append(problems, lintProblem{File: dir, Message: err.Error()})
+	} else if len(flags.Lint.SchemaDir) > 0 {
+		problems = append(problems, helmValidateManifests(manifests, flags.Lint)...)
+	}
+
+	if len(problems) > 0 {
+		for _, p := range problems {
+			log.Error(p.String())
+		}
+		return fmt.Errorf("%w: helm chart %s lint failed with %d problems", tools.ErrPrecondition, dir, len(problems))
+	}
+	log.Info("Helm chart lint done!", log.F("chart", dir))
+	return nil
+}
+
+func helmLintValues(files string) (map[string]interface{}, error) {
+	opts := values.Options{}
+	for _, f := range strings.Split(files, ",") {
+		f = strings.TrimSpace(f)
+		if len(f) > 0 {
+			opts.ValueFiles = append(opts.ValueFiles, f)
+		}
+	}
+	return opts.MergeValues(getter.Providers{})
+}
+
+func helmLintChart(dir string, vals map[string]interface{}, flags helmLintFlags) []lintProblem {
+	linter := lint.All(dir, vals, flags.Namespace, flags.Strict)
+
+	var problems []lintProblem
+	for _, msg := range linter.Messages {
+		switch {
+		case msg.Severity == support.ErrorSev:
+		case msg.Severity == support.WarningSev && flags.Strict:
+		default:
+			log.Info(msg.Error())
+			continue
+		}
+		problems = append(problems, lintProblem{File: msg.Path, Message: msg.Err.Error()})
+	}
+	return problems
+}
+
+// helmRenderTemplates render all chart templates with the default and the additional values
+func helmRenderTemplates(dir, name string, vals map[string]interface{}, namespace string) (map[string]string, error) {
+	c, err := loader.Load(dir)
+	if err != nil {
+		return nil, err
+	}
+	options := chartutil.ReleaseOptions{Name: name, Namespace: namespace, IsInstall: true}
+	renderValues, err := chartutil.ToRenderValues(c, vals, options, chartutil.DefaultCapabilities)
+	if err != nil {
+		return nil, err
+	}
+	rendered, err := engine.Render(c, renderValues)
+	if err != nil {
+		return nil, err
+	}
+
+	result := map[string]string{}
+	for file, content := range rendered {
+		ext := filepath.Ext(file)
+		if ext != ".yaml" && ext != ".yml" {
+			continue
+		}
+		if strings.HasPrefix(filepath.Base(file), "_") || len(strings.TrimSpace(content)) == 0 {
+			continue
+		}
+		result[file] = content
+	}
+	return result, nil
+}
+
+// helmValidateManifests validate all rendered manifests against the kubernetes schemas
+func helmValidateManifests(manifests map[string]string, flags helmLintFlags) []lintProblem {
+	compiler := jsonschema.NewCompiler()
+	schemas := map[string]*jsonschema.Schema{}
+	printer := message.NewPrinter(language.English)
+
+	files := make([]string, 0, len(manifests))
+	for file := range manifests {
+		files = append(files, file)
+	}
+	sort.Strings(files)
+
+	var problems []lintProblem
+	for _, file := range files {
+		dec := yaml.NewDecoder(strings.NewReader(manifests[file]))
+		for {
+			node := &yaml.Node{}
+			err := dec.Decode(node)
+			if err != nil {
+				if !errors.Is(err, io.EOF) {
+					problems = append(problems, lintProblem{File: file, Message: err.Error()})
+				}
+				break
+			}
+			if len(node.Content) == 0 || node.Content[0].Kind != yaml.MappingNode {
+				continue
+			}
+			doc := node.Content[0]
+
+			apiVersion := yamlNodeValue(doc, "apiVersion")
+			kind := yamlNodeValue(doc, "kind")
+			if len(apiVersion) == 0 || len(kind) == 0 {
+				problems = append(problems, lintProblem{File: file, Line: doc.Line, Message: "missing apiVersion or kind"})
+				continue
+			}
+
+			key := apiVersion + "/" + kind
+			schema, exists := schemas[key]
+			if !exists {
+				schema, err = helmLoadSchema(compiler, flags.SchemaDir, apiVersion, kind)
+				if err != nil {
+					problems = append(problems, lintProblem{File: file, Line: doc.Line, Message: err.Error()})
+					continue
+				}
+				schemas[key] = schema
+			}
+			if schema == nil {
+				if !flags.IgnoreMissingSchemas {
+					problems = append(problems, lintProblem{File: file, Line: doc.Line, Message: "missing schema for " + key})
+				}
+				continue
+			}
+
+			instance, err := yamlNodeToJSON(doc)
+			if err != nil {
+				problems = append(problems, lintProblem{File: file, Line: doc.Line, Message: err.Error()})
+				continue
+			}
+
+			err = schema.Validate(instance)
+			var verr *jsonschema.ValidationError
+			if errors.As(err, &verr) {
+				for _, cause := range validationLeafs(verr) {
+					problems = append(problems, lintProblem{
+						File:    file,
+						Line:    yamlNodeLine(doc, cause.InstanceLocation),
+						Message: kind + " /" + strings.Join(cause.InstanceLocation, "/") + ": " + cause.ErrorKind.LocalizedString(printer),
+					})
+				}
+			} else if err != nil {
+				problems = append(problems, lintProblem{File: file, Line: doc.Line, Message: err.Error()})
+			}
+		}
+	}
+	return problems
+}
+
+// helmLoadSchema load and compile the schema of the resource. Returns nil schema if the schema file does not exist.
+func helmLoadSchema(compiler *jsonschema.Compiler, dir, apiVersion, kind string) (*jsonschema.Schema, error) {
+	group := ""
+	version := apiVersion
+	if i := strings.LastIndex(apiVersion, "/"); i >= 0 {
+		group = apiVersion[:i]
+		version = apiVersion[i+1:]
+	}
+	k := strings.ToLower(kind)
+
+	var candidates []string
+	if len(group) > 0 {
+		candidates = append(candidates,
+			filepath.Join(dir, k+"-"+strings.Split(group, ".")[0]+"-"+version+".json"),
+			filepath.Join(dir, group, k+"_"+version+".json"),
+		)
+	} else {
+		candidates = append(candidates, filepath.Join(dir, k+"-"+version+".json"))
+	}
+
+	for _, file := range candidates {
+		if _, err := os.Stat(file); err != nil {
+			continue
+		}
+		abs, err := filepath.Abs(file)
+		if err != nil {
+			return nil, err
+		}
+		log.Debug("Kubernetes schema", log.F("kind", kind).F("apiVersion", apiVersion).F("file", abs))
+		return compiler.Compile(abs)
+	}
+	return nil, nil
+}
+
+func validationLeafs(err *jsonschema.ValidationError) []*jsonschema.ValidationError {
+	if len(err.Causes) == 0 {
+		return []*jsonschema.ValidationError{err}
+	}
+	var result []*jsonschema.ValidationError
+	for _, c := range err.Causes {
+		result = append(result, validationLeafs(c)...)
+	}
+	return result
+}
+
+func yamlNodeValue(node *yaml.Node, key string) string {
+	for i := 0; i+1 < len(node.Content); i += 2 {
+		if node.Content[i].Value == key {
+			return node.Content[i+1].Value
+		}
+	}
+	return ""
+}
+
+// yamlNodeLine find the line of the instance location in the yaml document
+func yamlNodeLine(node *yaml.Node, location []string) int {
+	line := node.Line
+	for _, item := range location {
+		var next *yaml.Node
+		switch node.Kind {
+		case yaml.MappingNode:
+			for i := 0; i+1 < len(node.Content); i += 2 {
+				if node.Content[i].Value == item {
+					line = node.Content[i].Line
+					next = node.Content[i+1]
+					break
+				}
+			}
+		case yaml.SequenceNode:
+			var index int
+			if _, err := fmt.Sscanf(item, "%d", &index); err == nil && index < len(node.Content) {
+				next = node.Content[index]
+				line = next.Line
+			}
+		}
+		if next == nil {
+			return line
+		}
+		node = next
+	}
+	return line
+}
+
+func yamlNodeToJSON(node *yaml.Node) (interface{}, error) {
+	var obj interface{}
+	if err := node.Decode(&obj); err != nil {
+		return nil, err
+	}
+	data, err := json.Marshal(obj)
+	if err != nil {
+		return nil, err
+	}
+	return jsonschema.UnmarshalJSON(bytes.NewReader(data))
+}
@@ -1,10 +1,13 @@
 package cmd
 
 import (
+	"context"
+	"fmt"
+	"os"
+
 	"github.com/lorislab/samo/log"
-	"github.com/lorislab/samo/tools"
+	"github.com/lorislab/samo/pkg/samo"
 	"github.com/spf13/cobra"
-	"os"
 )
 
 type helmLockUpdateFlags struct {
@@ -17,11 +20,16 @@ func createHelmLockUpdateCmd() *cobra.Command {
 		Use:   "lock-update",
 		Short: "Update Chart.lock file",
 		Long:  `Update the Helm chart Chart.lock or create a new one if it doesn't exist`,
-		Run: func(cmd *cobra.Command, args []string) {
+		RunE: func(cmd *cobra.Command, args []string) error {
 			flags := helmLockUpdateFlags{}
-			readOptions(&flags)
-			project := loadProject(flags.Helm.Project)
-			helmLockUpdate(project, flags)
+			if err := readOptions(&flags); err != nil {
+				return err
+			}
+			project, err := loadProject(cmd.Context(), flags.Helm.Project)
+			if err != nil {
+				return err
+			}
+			return helmLockUpdate(cmd.Context(), project, flags)
 		},
 		TraverseChildren: true,
 	}
@@ -30,22 +38,29 @@ func createHelmLockUpdateCmd() *cobra.Command {
 	return cmd
 }
 
-func helmLockUpdate(project *Project, flags helmLockUpdateFlags) {
+func helmLockUpdate(ctx context.Context, project *Project, flags helmLockUpdateFlags) error {
 
 	// add repo from chart dependencies
 	if flags.Helm.AddRepoDeps {
-		chart := loadChart(project, flags.Helm)
-		helmAddRepoDeps(chart)
+		chart, err := loadChart(project, flags.Helm)
+		if err != nil {
+			return err
+		}
+		if err := helmAddRepoDeps(ctx, chart); err != nil {
+			return err
+		}
 	}
 
 	dir := helmDir(project, flags.Helm)
 
 	// update helm Chart.lock
-	tools.ExecCmd("helm", "dependency", "update", dir)
+	if err := samo.Run(ctx, samo.HelmDependency("update", dir)); err != nil {
+		return err
+	}
 
 	// keep chart directory?
 	if flags.KeepCharts {
-		return
+		return nil
 	}
 
 	charts := dir + "/charts"
@@ -53,8 +68,8 @@ func helmLockUpdate(project *Project, flags helmLockUpdateFlags) {
 		log.Debug("Clean helm dependencies directory", log.F("dir", charts))
 		err := os.RemoveAll(charts)
 		if err != nil {
-			log.Panic("error delete directory", log.F("output", charts).E(err))
+			return fmt.Errorf("error delete directory %s: %w", charts, err)
 		}
 	}
-
+	return nil
 }
@@ -9,12 +9,16 @@ func createHelmPushCmd() *cobra.Command {
 		Use:   "push",
 		Short: "Push helm chart",
 		Long:  `Push helm chart to the helm repository`,
-		Run: func(cmd *cobra.Command, args []string) {
-
+		RunE: func(cmd *cobra.Command, args []string) error {
 			flags := helmFlags{}
-			readOptions(&flags)
-			project := loadProject(flags.Project)
-			helmPush(project.Version(), project, flags)
+			if err := readOptions(&flags); err != nil {
+				return err
+			}
+			project, err := loadProject(cmd.Context(), flags.Project)
+			if err != nil {
+				return err
+			}
+			return helmPush(cmd.Context(), project.Version(), project, flags)
 		},
 		TraverseChildren: true,
 	}
@@ -1,7 +1,11 @@
 package cmd
 
 import (
+	"context"
+	"fmt"
+
 	"github.com/lorislab/samo/log"
+	"github.com/lorislab/samo/pkg/samo"
 	"github.com/lorislab/samo/tools"
 	"github.com/spf13/cobra"
 )
@@ -17,11 +21,16 @@ func createHelmReleaseCmd() *cobra.Command {
 		Use:   "release",
 		Short: "Release helm chart",
 		Long:  `Download version of the helm chart and create final version`,
-		Run: func(cmd *cobra.Command, args []string) {
+		RunE: func(cmd *cobra.Command, args []string) error {
 			flags := helmReleaseFlags{}
-			readOptions(&flags)
-			project := loadProject(flags.Helm.Project)
-			helmRelease(project, flags)
+			if err := readOptions(&flags); err != nil {
+				return err
+			}
+			project, err := loadProject(cmd.Context(), flags.Helm.Project)
+			if err != nil {
+				return err
+			}
+			return helmRelease(cmd.Context(), project, flags)
 		},
 		TraverseChildren: true,
 	}
@@ -35,58 +44,72 @@ func createHelmReleaseCmd() *cobra.Command {
 	return cmd
 }
 
-func helmRelease(pro *Project, flags helmReleaseFlags) {
+func helmRelease(ctx context.Context, pro *Project, flags helmReleaseFlags) error {
 
 	if pro.Count() != "0" || len(pro.Tag()) == 0 {
-		log.Fatal("Can not created helm release. Missing tag on current commit",
-			log.Fields{"version": pro.Version(), "hash": pro.Hash(), "count": pro.Count(), "tag": pro.Tag()})
+		return fmt.Errorf("%w: can not created helm release, missing tag on current commit (version: %s, hash: %s, count: %s, tag: %s)",
+			tools.ErrNoTag, pro.Version(), pro.Hash(), pro.Count(), pro.Tag())
+	}
+
+	if err := verifyReleaseTag(ctx, pro, flags.Helm.Project); err != nil {
+		return err
 	}
 
 	// switch back to rc version
-	pro.switchBackToReleaseCandidate()
+	pro.SwitchBackToReleaseCandidate()
 	log.Info("Create helm release", log.Fields{"version": pro.Version(), "release": pro.Release()})
 
 	// clean helm dir
-	helmClean(flags.Helm)
+	if err := helmClean(flags.Helm); err != nil {
+		return err
+	}
 
 	// add and update custom helm repo
-	helmAddRepo(flags.Helm)
+	if err := helmAddRepo(ctx, flags.Helm); err != nil {
+		return err
+	}
 
 	// download build version
-	helmDownload(pro, flags.Helm)
+	if err := helmDownload(ctx, pro, flags.Helm); err != nil {
+		return err
+	}
 
 	// update version to release version
-	updateHelmChart(pro, flags.Helm, flags.ChartReleaseTemplate)
-	updateHelmValues(pro, flags.Helm, flags.ValuesReleaseTemplate)
+	if err := updateHelmChart(pro, flags.Helm, flags.ChartReleaseTemplate); err != nil {
+		return err
+	}
+	if err := updateHelmValues(pro, flags.Helm, flags.ValuesReleaseTemplate); err != nil {
+		return err
+	}
 
 	// package helm chart
-	helmPackage(pro, flags.Helm)
+	if err := helmPackage(ctx, pro, flags.Helm); err != nil {
+		return err
+	}
 
 	// upload helm chart with release version
-	helmPush(pro.Release(), pro, flags.Helm)
+	return helmPush(ctx, pro.Release(), pro, flags.Helm)
 }
 
-func helmDownload(project *Project, flags helmFlags) {
+func helmDownload(ctx context.Context, project *Project, flags helmFlags) error {
 
+	chart := flags.Registry + "/" + project.Name()
+	// deprecated
 	if len(flags.Registry) == 0 {
-		helmDownloadRepository(project, flags)
-		return
+		chart = flags.Repo + "/" + project.Name()
+	} else if err := registryLogin(ctx, registryHelm, helmRegistryAuth(flags.Registry, flags.RegistryAuth)); err != nil {
+		return err
+	}
+	if flags.Verify {
+		log.Info("Verify helm chart provenance file")
 	}
 
-	var command []string
-	command = append(command, "pull")
-	command = append(command, flags.Registry+"/"+project.Name())
-	command = append(command, "--version", project.Version())
-	command = append(command, "--untar", "--untardir", flags.Dir)
-	tools.ExecCmd("helm", command...)
-}
-
-// deprecated
-func helmDownloadRepository(project *Project, flags helmFlags) {
-	var command []string
-	command = append(command, "pull")
-	command = append(command, flags.Repo+"/"+project.Name())
-	command = append(command, "--version", project.Version())
-	command = append(command, "--untar", "--untardir", flags.Dir)
-	tools.ExecCmd("helm", command...)
+	pull := samo.HelmPull{
+		Chart:   chart,
+		Version: project.Version(),
+		Dir:     flags.Dir,
+		Verify:  flags.Verify,
+		Keyring: flags.VerifyKeyring,
+	}
+	return samo.Run(ctx, pull.Command())
 }
@@ -0,0 +1,123 @@
+package cmd
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/lorislab/samo/log"
+	"github.com/lorislab/samo/tools"
+	"github.com/spf13/cobra"
+)
+
+const helmSchemaDraft = "http://json-schema.org/draft-07/schema#"
+
+type helmSchemaFlags struct {
+	Helm  helmFlags `mapstructure:",squash"`
+	Check bool      `mapstructure:"helm-schema-check"`
+}
+
+func createHelmSchemaCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "schema",
+		Short: "Generate helm chart values.schema.json",
+		Long: `Generate the values.schema.json (JSON schema draft-07) of the helm chart from the values.yaml.
+The types are inferred from the values. Use the comment annotations to extend the schema of the value:
+  # @schema type: string
+  # @schema enum: [ Always, IfNotPresent, Never ]
+  # @schema required: true
+  # @schema description: image pull policy
+  pullPolicy: IfNotPresent`,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			flags := helmSchemaFlags{}
+			if err := readOptions(&flags); err != nil {
+				return err
+			}
+			project, err := loadProject(cmd.Context(), flags.Helm.Project)
+			if err != nil {
+				return err
+			}
+			return helmSchema(project, flags)
+		},
+		TraverseChildren: true,
+	}
+
+	addBoolFlag(cmd, "helm-schema-check", "", false, "check the values.schema.json is up to date, do not write the file")
+	return cmd
+}
+
+func helmSchema(project *Project, flags helmSchemaFlags) error {
+	dir := helmDir(project, flags.Helm)
+	valuesFile := filepath.Join(dir, "values.yaml")
+	schemaFile := filepath.Join(dir, "values.schema.json")
+
+	values, err := loadHelmValues(valuesFile)
+	if err != nil {
+		return fmt.Errorf("error read helm values file %s: %w", valuesFile, err)
+	}
+
+	schema := helmValueSchema(values)
+	schema["$schema"] = helmSchemaDraft
+
+	var buf bytes.Buffer
+	enc := json.NewEncoder(&buf)
+	enc.SetEscapeHTML(false)
+	enc.SetIndent("", "  ")
+	if err := enc.Encode(schema); err != nil {
+		return fmt.Errorf("error marshal values schema: %w", err)
+	}
+
+	if flags.Check {
+		current, err := os.ReadFile(schemaFile)
+		if err != nil || !bytes.Equal(current, buf.Bytes()) {
+			return fmt.Errorf("%w: helm values schema %s is out of date, run 'samo project helm schema' to update it", tools.ErrPrecondition, schemaFile)
+		}
+		log.Info("Helm values schema is up to date.", log.F("file", schemaFile))
+		return nil
+	}
+
+	if err := tools.WriteBytesToFile(schemaFile, buf.Bytes()); err != nil {
+		return err
+	}
+	log.Info("Helm values schema created.", log.F("file", schemaFile))
+	return nil
+}
+
+// helmValueSchema create JSON schema of the value. The annotations overwrite the inferred keywords.
+func helmValueSchema(value *helmValue) map[string]interface{} {
+	result := map[string]interface{}{}
+	if len(value.Type) > 0 {
+		result["type"] = value.Type
+	}
+	if len(value.Description) > 0 {
+		result["description"] = value.Description
+	}
+
+	if len(value.Children) > 0 {
+		properties := map[string]interface{}{}
+		var required []string
+		for _, child := range value.Children {
+			properties[child.Name] = helmValueSchema(child)
+			if child.Required() {
+				required = append(required, child.Name)
+			}
+		}
+		result["properties"] = properties
+		if len(required) > 0 {
+			result["required"] = required
+		}
+	}
+	if value.Items != nil {
+		result["items"] = helmValueSchema(value.Items)
+	}
+
+	for k, v := range value.Schema {
+		if k == "required" {
+			continue
+		}
+		result[k] = v
+	}
+	return result
+}
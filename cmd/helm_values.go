@@ -0,0 +1,138 @@
+package cmd
+
+import (
+	"os"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+const helmSchemaAnnotation = "@schema"
+
+// helmValue value definition from the values.yaml
+type helmValue struct {
+	Key         string
+	Name        string
+	Type        string
+	Default     interface{}
+	Description string
+	Schema      map[string]interface{}
+	Children    []*helmValue
+	Items       *helmValue
+}
+
+// Required the value is marked as required by the '# @schema required: true' annotation
+func (v *helmValue) Required() bool {
+	r, ok := v.Schema["required"].(bool)
+	return ok && r
+}
+
+// loadHelmValues load the values.yaml file with comments and schema annotations
+func loadHelmValues(filename string) (*helmValue, error) {
+	data, err := os.ReadFile(filename)
+	if err != nil {
+		return nil, err
+	}
+	doc := &yaml.Node{}
+	if err := yaml.Unmarshal(data, doc); err != nil {
+		return nil, err
+	}
+	root := &helmValue{Type: "object", Schema: map[string]interface{}{}}
+	if len(doc.Content) > 0 && doc.Content[0].Kind == yaml.MappingNode {
+		if err := parseHelmValueChildren(root, doc.Content[0]); err != nil {
+			return nil, err
+		}
+	}
+	return root, nil
+}
+
+func parseHelmValueChildren(parent *helmValue, node *yaml.Node) error {
+	for i := 0; i+1 < len(node.Content); i += 2 {
+		key := node.Content[i]
+		value := node.Content[i+1]
+
+		item := &helmValue{Name: key.Value, Key: key.Value}
+		if len(parent.Key) > 0 {
+			item.Key = parent.Key + "." + key.Value
+		}
+		if err := parseHelmValue(item, key.HeadComment, value); err != nil {
+			return err
+		}
+		parent.Children = append(parent.Children, item)
+	}
+	return nil
+}
+
+func parseHelmValue(item *helmValue, comment string, node *yaml.Node) error {
+	item.Type = helmValueType(node)
+	item.Description, item.Schema = parseHelmValueComment(comment)
+
+	switch node.Kind {
+	case yaml.MappingNode:
+		if err := parseHelmValueChildren(item, node); err != nil {
+			return err
+		}
+	case yaml.SequenceNode:
+		if len(node.Content) > 0 {
+			item.Items = &helmValue{Key: item.Key + "[]"}
+			if err := parseHelmValue(item.Items, node.Content[0].HeadComment, node.Content[0]); err != nil {
+				return err
+			}
+		}
+	}
+
+	var def interface{}
+	if err := node.Decode(&def); err != nil {
+		return err
+	}
+	item.Default = def
+	return nil
+}
+
+func helmValueType(node *yaml.Node) string {
+	switch node.Kind {
+	case yaml.MappingNode:
+		return "object"
+	case yaml.SequenceNode:
+		return "array"
+	case yaml.AliasNode:
+		return helmValueType(node.Alias)
+	}
+	switch node.ShortTag() {
+	case "!!int":
+		return "integer"
+	case "!!float":
+		return "number"
+	case "!!bool":
+		return "boolean"
+	case "!!str", "!!timestamp", "!!binary":
+		return "string"
+	}
+	return ""
+}
+
+// parseHelmValueComment split the key comment to the description and '# @schema <keyword>: <value>' annotations
+func parseHelmValueComment(comment string) (string, map[string]interface{}) {
+	schema := map[string]interface{}{}
+	var description []string
+	for _, line := range strings.Split(comment, "\n") {
+		line = strings.TrimSpace(strings.TrimLeft(strings.TrimSpace(line), "#"))
+		if len(line) == 0 {
+			continue
+		}
+		if !strings.HasPrefix(line, helmSchemaAnnotation) {
+			description = append(description, line)
+			continue
+		}
+		kv := strings.SplitN(strings.TrimSpace(strings.TrimPrefix(line, helmSchemaAnnotation)), ":", 2)
+		if len(kv) < 2 {
+			continue
+		}
+		var value interface{}
+		if err := yaml.Unmarshal([]byte(kv[1]), &value); err != nil {
+			value = strings.TrimSpace(kv[1])
+		}
+		schema[strings.TrimSpace(kv[0])] = value
+	}
+	return strings.Join(description, " "), schema
+}
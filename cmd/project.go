@@ -1,35 +1,36 @@
 package cmd
 
 import (
+	"context"
+	"fmt"
 	"os"
-	"path/filepath"
-	"regexp"
-	"strings"
 
 	"github.com/Masterminds/semver/v3"
-	"github.com/lorislab/samo/log"
+	"github.com/lorislab/samo/pkg/samo"
 	"github.com/lorislab/samo/tools"
 	"github.com/spf13/cobra"
-	cc "gitlab.com/digitalxero/go-conventional-commit"
 )
 
 type projectFlags struct {
 	FirstVersion        string `mapstructure:"first-version"`
 	ReleaseMajor        bool   `mapstructure:"release-major"`
 	ReleasePatch        bool   `mapstructure:"release-patch"`
+	ReleaseAs           bool   `mapstructure:"release-as-trailer"`
 	VersionTemplate     string `mapstructure:"version-template"`
 	SkipPush            bool   `mapstructure:"skip-push"`
 	ConventionalCommits bool   `mapstructure:"conventional-commits"`
 	BranchTemplate      string `mapstructure:"branch-template"`
+	ReleaseBranch       string `mapstructure:"release-branch-template"`
+	TagTemplate         string `mapstructure:"release-tag-template"`
 	SkipLabels          bool   `mapstructure:"skip-samo-labels"`
 	LabelTemplate       string `mapstructure:"labels-template-list"`
 	Description         string `mapstructure:"description"`
 	Url                 string `mapstructure:"url"`
 	ProjectName         string `mapstructure:"project-name"`
+	VerifyTag           bool   `mapstructure:"release-verify-tag"`
+	AllowedSigners      string `mapstructure:"verify-allowed-signers"`
 }
 
-var sourceLinkRegex = `\/\/.*@`
-
 var templateValues = `Name,Tag,Hash,Count,Branch,Version,Release,Major,Minor,Patch,Prerelease`
 
 func createProjectCmd() *cobra.Command {
@@ -46,6 +47,7 @@ func createProjectCmd() *cobra.Command {
 	addStringFlag(cmd, "first-version", "", "0.0.0", "the first version of the project")
 	addBoolFlag(cmd, "release-major", "", false, "create a major release")
 	addBoolFlag(cmd, "release-patch", "", false, "create a patch release")
+	addBoolFlag(cmd, "release-as-trailer", "", false, "read the Release-As trailer of the commits since the last tag, always enabled for the conventional commits")
 	addStringFlag(cmd, "version-template", "t", "{{ .Version }}-rc.{{ .Count }}", `the version go template string.
 	values: `+templateValues+`
 	functions:  trunc <length>
@@ -53,18 +55,31 @@ func createProjectCmd() *cobra.Command {
 	addBoolFlag(cmd, "skip-push", "", false, "skip push changes")
 	addBoolFlag(cmd, "conventional-commits", "c", false, "determine the project version based on the conventional commits")
 	addStringFlag(cmd, "branch-template", "", "fix/{{ .Major }}.{{ .Minor }}.x", "patch-branch name template. Values: Major,Minor,Patch")
+	addStringFlag(cmd, "release-branch-template", "", "", `release-branch name template, the release branches are disabled by default. Values: Major,Minor,Patch.
+	The release branch has the release candidate versions of X.Y.0. Example: release/{{ .Major }}.{{ .Minor }}`)
+
+	addStringFlag(cmd, "release-tag-template", "", "{{ .Release }}", `the release tag template of the release and the backport.
+	Values: `+templateValues)
 
 	addBoolFlag(cmd, "skip-samo-labels", "", false, "skip samo labels/annotations samo.project.revision,samo.project.version,samo.project.created")
 	addStringFlag(cmd, "labels-template-list", "", "", `custom labels template list.
 	Values: `+templateValues+`
 	Example: my-label={{ .Branch }},my-const=123,my-count={{ .Count }}`)
 
-    	addStringFlag(cmd, "project-name", "", "", "alternate name for the project")
+	addStringFlag(cmd, "project-name", "", "", "alternate name for the project")
+	addBoolFlag(cmd, "release-verify-tag", "", false, "docker and helm release require a valid signature of the tag at HEAD")
+	addStringFlag(cmd, "verify-allowed-signers", "", "", "the allowed signers file of the SSH signature verification (gpg.ssh.allowedSignersFile)")
 
 	addChildCmd(cmd, createProjectVersionCmd())
 	addChildCmd(cmd, createProjectNameCmd())
 	addChildCmd(cmd, createProjectReleaseCmd())
 	addChildCmd(cmd, createProjectPatchCmd())
+	addChildCmd(cmd, createProjectCICmd())
+	addChildCmd(cmd, createProjectVerifyTagCmd())
+	addChildCmd(cmd, createProjectBackportCmd())
+	addChildCmd(cmd, createProjectReleaseBranchCmd())
+	addChildCmd(cmd, createProjectReleasesCmd())
+	addChildCmd(cmd, createProjectLintCommitsCmd())
 	addChildCmd(cmd, createDockerCmd())
 	addChildCmd(cmd, createHelmCmd())
 
@@ -72,271 +87,32 @@ func createProjectCmd() *cobra.Command {
 }
 
 // Project common project interface
-type Project struct {
-	name        string
-	describe    tools.GitDescribe
-	rc          tools.GitDescribe
-	branch      string
-	source      string
-	url         string
-	description string
-	patchBuild  bool
-	version     *semver.Version
-	rcVersion   *semver.Version
-	release     *semver.Version
-	rcRelease   *semver.Version
-}
-
-// Name project name
-func (g Project) Name() string {
-	return g.name
-}
+type Project = samo.Project
 
-func (g Project) Source() string {
-	return g.source
-}
-
-func (g Project) Major() uint64 {
-	return g.version.Major()
-}
-
-func (g Project) Minor() uint64 {
-	return g.version.Minor()
-}
-
-func (g Project) Patch() uint64 {
-	return g.version.Patch()
-}
-
-func (g Project) Prerelease() string {
-	return g.version.Prerelease()
-}
-
-func (g Project) Version() string {
-	return g.version.String()
-}
-
-func (g Project) Description() string {
-	return g.description
-}
-
-func (g Project) Url() string {
-	return g.url
-}
-
-func (g Project) Release() string {
-	return g.release.String()
-}
-
-func (g Project) Hash() string {
-	return g.describe.Hash
-}
-
-func (g Project) Branch() string {
-	return g.branch
-}
-
-func (g Project) Count() string {
-	return g.describe.Count
-}
-
-func (g Project) Tag() string {
-	return g.describe.Tag
-}
-
-func (g Project) IsPatchBuild() bool {
-	return g.patchBuild
-}
-
-func (g *Project) switchBackToReleaseCandidate() {
-	g.version = g.rcVersion
-	g.release = g.rcRelease
-}
-
-func loadProject(flags projectFlags) *Project {
+func loadProject(ctx context.Context, flags projectFlags) (*Project, error) {
 
 	if _, err := os.Stat(".git"); os.IsNotExist(err) {
-		log.Fatal("Missing git directory!", log.F("directory", ".git"))
-	}
-
-	// read repository git url or directory name
-	tmp, err := tools.CmdOutputErr("git", "config", "remote.origin.url")
-	if err != nil {
-		tmp = tools.ExecCmdOutput("git", "rev-parse", "--show-toplevel")
+		return nil, fmt.Errorf("%w: missing git directory .git", tools.ErrPrecondition)
 	}
 
-	// create project source
-	source := tmp
-	reg, _ := regexp.Compile(sourceLinkRegex)
-	source = reg.ReplaceAllString(source, `//`)
-	log.Debug("Project", log.F("source", source))
-
-	// create project name
-	name := flags.ProjectName
-	if len(name) == 0 {
-    		name = "no-name"
-    		tmp = strings.TrimSuffix(tmp, ".git")
-    		tmp = filepath.Base(tmp)
-   		if len(tmp) > 0 && tmp != "." && tmp != "/" {
-        		name = tmp
-    		}
+	var strategy samo.VersionStrategy = samo.DefaultStrategy{Major: flags.ReleaseMajor, Patch: flags.ReleasePatch, ReleaseAs: flags.ReleaseAs}
+	if flags.ConventionalCommits {
+		strategy = samo.ConventionalCommitsStrategy{}
 	}
 
-	describe := tools.GitDescribeInfo()
-	rc := describe
-
-	branch := tools.GitBranch()
-	patchBuild := false
-
-	version := flags.FirstVersion
-	lastRC := version
-
-	// check for empty repository
-	if len(describe.Tag) > 0 {
-		ver := tools.CreateSemVer(describe.Tag)
-		patchBranch := createPatchBranchName(ver, flags)
-
-		// branch name is patch branch or version is patch
-		patchBuild = (branch == patchBranch) || ver.Patch() > 0
-
-		log.Debug("Branch", log.Fields{"branch": branch, "patchBranch": patchBranch, "patchBuild": patchBuild, "count": describe.Count})
-
-		// create version
-		if flags.ConventionalCommits {
-			version = createNextVersionConventionalCommits(ver, patchBuild, describe)
-		} else {
-			version = createNextVersion(ver, flags.ReleaseMajor, flags.ReleasePatch, patchBuild)
-		}
-
-		// check last rc version
-		if describe.Count == "0" {
-
-			// find last tag before release
-			rc = tools.GitDescribeExclude(describe.Tag)
-			if len(rc.Tag) > 0 {
-				rcVer := tools.CreateSemVer(rc.Tag)
-				if flags.ConventionalCommits {
-					lastRC = createNextVersionConventionalCommits(rcVer, patchBuild, rc)
-				} else {
-					lastRC = createNextVersion(rcVer, false, false, patchBuild)
-				}
-			}
-		} else {
-			lastRC = version
-		}
+	opts := samo.Options{
+		Name:                  flags.ProjectName,
+		FirstVersion:          flags.FirstVersion,
+		VersionTemplate:       flags.VersionTemplate,
+		BranchTemplate:        flags.BranchTemplate,
+		ReleaseBranchTemplate: flags.ReleaseBranch,
+		Description:           flags.Description,
+		URL:                   flags.Url,
+		Strategy:              strategy,
 	}
-
-	var url = flags.Url
-	if len(url) == 0 {
-		// remove .git suffix
-		url = strings.TrimSuffix(source, ".git")
-
-		// replace git@server:path -> https://server/path
-		if strings.HasPrefix(url, "git@") {
-			url = strings.TrimPrefix(url, "git@")
-			url = strings.Replace(url, ":", "/", 1)
-			url = "https://" + url
-		}
-
-	}
-
-	var description = flags.Description
-	if len(description) == 0 {
-		description = describe.Hash
-	}
-
-	p := &Project{
-		name:        name,
-		describe:    describe,
-		branch:      branch,
-		source:      source,
-		description: description,
-		patchBuild:  patchBuild,
-		url:         url,
-		rc:          rc,
-		rcVersion:   createVersion(lastRC, branch, flags.VersionTemplate, rc),
-		rcRelease:   tools.CreateSemVer(lastRC),
-		version:     createVersion(version, branch, flags.VersionTemplate, describe),
-		release:     tools.CreateSemVer(version),
-	}
-	log.Debug("Versions", log.Fields{"version": p.Version(), "release": p.Release(), "rcVersion": p.rcVersion.String(), "rcRelease": p.rcRelease.String()})
-	return p
-}
-
-func createPatchBranchName(version *semver.Version, flags projectFlags) string {
-	return tools.Template(version, flags.BranchTemplate)
+	return samo.New(ctx, opts, samo.NewCommandGit())
 }
 
-func createVersion(version, branch, template string, describe tools.GitDescribe) *semver.Version {
-	data := struct {
-		Tag, Hash, Count, Branch, Version string
-	}{
-		Tag:     describe.Tag,
-		Hash:    describe.Hash,
-		Count:   describe.Count,
-		Branch:  branch,
-		Version: version,
-	}
-
-	tmp := tools.Template(data, template)
-	return tools.CreateSemVer(tmp)
-}
-
-func createNextVersion(ver *semver.Version, major, patch, patchBranch bool) string {
-
-	if patchBranch || patch || ver.Patch() != 0 {
-		tmp := ver.IncPatch()
-		return tmp.String()
-	}
-	if major {
-		if ver.Patch() != 0 {
-			log.Fatal("Can not created major release from the patch version!", log.F("version", ver.String()))
-		}
-		tmp := ver.IncMajor()
-		return tmp.String()
-	}
-	tmp := ver.IncMinor()
-	return tmp.String()
-}
-
-func createNextVersionConventionalCommits(ver *semver.Version, patchBranch bool, describe tools.GitDescribe) string {
-
-	// for patch branch we can ignore conventional commits
-	if patchBranch {
-		tmp := ver.IncPatch()
-		return tmp.String()
-	}
-
-	if describe.Count == "0" {
-		tmp := ver.IncMinor()
-		return tmp.String()
-	}
-
-	commits := tools.GitLogMessages(ver.String(), "HEAD")
-	commit := findConvCommit(commits)
-	if commit.Major {
-		tmp := ver.IncMajor()
-		return tmp.String()
-	}
-	tmp := ver.IncMinor()
-	return tmp.String()
-}
-
-func findConvCommit(commits []string) *cc.ConventionalCommit {
-	var result *cc.ConventionalCommit
-	for _, commit := range commits {
-		item := cc.ParseConventionalCommit(strings.TrimPrefix(strings.TrimSuffix(commit, `"`), `"`))
-		if item.Major {
-			log.Debug("Major", log.F("commit", item))
-			return item
-		}
-		if result == nil {
-			result = item
-		} else {
-			if !result.Minor {
-				result = item
-			}
-		}
-	}
-	return result
+func createPatchBranchName(version *semver.Version, flags projectFlags) (string, error) {
+	return samo.PatchBranchName(version, flags.BranchTemplate)
 }
@@ -0,0 +1,374 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/Masterminds/semver/v3"
+	"github.com/lorislab/samo/log"
+	"github.com/lorislab/samo/tools"
+	"github.com/spf13/cobra"
+)
+
+type projectBackportFlags struct {
+	Project  projectFlags `mapstructure:",squash"`
+	Commit   string       `mapstructure:"commit"`
+	To       string       `mapstructure:"to"`
+	Trailers bool         `mapstructure:"backport-trailers"`
+	From     string       `mapstructure:"backport-from"`
+}
+
+// backportTrailer trailer of the commit with the comma separated list of the minor versions
+const backportTrailer = "Backport"
+
+// backportResult result of the backport to the patch branch
+type backportResult struct {
+	Branch  string
+	Commits []string
+	Status  string
+	Details string
+}
+
+func createProjectBackportCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "backport",
+		Short: "Backport commits to the patch branches",
+		Long: `Cherry-pick the commits with -x to the patch branches of the minor versions.
+The missing patch branches are created from the release tag X.Y.0. The backport to the branch with conflicts is aborted and reported.
+Example:
+  samo project backport --commit 3f2a1c9 --to 1.2,1.3
+  samo project backport --backport-trailers
+
+The commits with the trailer 'Backport: 1.2, 1.3' are selected with --backport-trailers.`,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			flags := projectBackportFlags{}
+			if err := readOptions(&flags); err != nil {
+				return err
+			}
+			project, err := loadProject(cmd.Context(), flags.Project)
+			if err != nil {
+				return err
+			}
+			return backport(cmd.Context(), project, flags)
+		},
+		TraverseChildren: true,
+	}
+
+	addStringFlag(cmd, "commit", "", "", "comma separated list of the commits to backport")
+	addStringFlag(cmd, "to", "", "", "comma separated list of the minor versions of the patch branches. Example: 1.2,1.3")
+	addBoolFlag(cmd, "backport-trailers", "", false, "backport the commits with the trailer '"+backportTrailer+": <versions>'")
+	addStringFlag(cmd, "backport-from", "", "", "start of the commit range for the trailers. Default the last release tag")
+
+	return cmd
+}
+
+func backport(ctx context.Context, project *Project, flags projectBackportFlags) error {
+
+	plan, err := backportPlan(ctx, project, flags)
+	if err != nil {
+		return err
+	}
+	if len(plan) == 0 {
+		log.Info("No commits to backport")
+		return nil
+	}
+
+	status, err := tools.Output(ctx, tools.NewCommand("git", "status", "--porcelain", "--untracked-files=no"))
+	if err != nil {
+		return err
+	}
+	if len(status) > 0 {
+		return fmt.Errorf("%w: working tree is not clean, commit or stash the changes before the backport", tools.ErrPrecondition)
+	}
+	current, err := tools.Output(ctx, tools.NewCommand("git", "rev-parse", "--abbrev-ref", "HEAD"))
+	if err != nil {
+		return err
+	}
+	// detached HEAD, restore the commit
+	if current == "HEAD" {
+		current, err = tools.Output(ctx, tools.NewCommand("git", "rev-parse", "HEAD"))
+		if err != nil {
+			return err
+		}
+	}
+	if !flags.Project.SkipPush {
+		fetch := tools.NewCommand("git", "fetch", "--quiet", "origin")
+		fetch.Retry = true
+		if err := tools.Run(ctx, fetch); err != nil {
+			return err
+		}
+	}
+
+	versions := make([]*semver.Version, 0, len(plan))
+	for v := range plan {
+		versions = append(versions, v)
+	}
+	sort.Sort(semver.Collection(versions))
+
+	var results []backportResult
+	var failed []string
+	for _, v := range versions {
+		result, created := backportBranch(ctx, project, v, plan[v], flags)
+		if err := tools.Run(ctx, tools.NewCommand("git", "checkout", "--quiet", current)); err != nil {
+			return err
+		}
+		if result.Status != "done" {
+			failed = append(failed, result.Branch)
+			// remove the patch branch created by the failed backport
+			if created {
+				if err := tools.Run(ctx, tools.NewCommand("git", "branch", "-D", result.Branch)); err != nil {
+					return err
+				}
+			}
+		}
+		results = append(results, result)
+	}
+
+	printBackportSummary(results)
+	if len(failed) > 0 {
+		return fmt.Errorf("%w: backport failed for the branches %s", tools.ErrCommandFailed, strings.Join(failed, ","))
+	}
+	log.Info("Backport finished.", log.F("branches", len(results)))
+	return nil
+}
+
+// backportPlan commits of the minor versions in the order of the backport
+func backportPlan(ctx context.Context, project *Project, flags projectBackportFlags) (map[*semver.Version][]string, error) {
+	plan := map[*semver.Version][]string{}
+	add := func(minor, commit string) error {
+		minor = strings.TrimSpace(minor)
+		if len(minor) == 0 {
+			return nil
+		}
+		v, err := backportVersion(minor)
+		if err != nil {
+			return err
+		}
+		// the same minor version written as 1.2 or 1.2.0
+		for p := range plan {
+			if p.Equal(v) {
+				v = p
+				break
+			}
+		}
+		for _, c := range plan[v] {
+			if c == commit {
+				return nil
+			}
+		}
+		plan[v] = append(plan[v], commit)
+		return nil
+	}
+
+	if len(flags.Commit) > 0 || len(flags.To) > 0 {
+		if len(flags.Commit) == 0 || len(flags.To) == 0 {
+			return nil, fmt.Errorf("%w: the flags --commit and --to must be used together", tools.ErrInvalidInput)
+		}
+		for _, c := range strings.Split(flags.Commit, ",") {
+			hash, err := tools.Output(ctx, tools.NewCommand("git", "rev-parse", "--verify", "-q", strings.TrimSpace(c)+"^{commit}"))
+			if err != nil {
+				return nil, fmt.Errorf("%w: commit %s not found", tools.ErrInvalidInput, c)
+			}
+			for _, to := range strings.Split(flags.To, ",") {
+				if err := add(to, hash); err != nil {
+					return nil, err
+				}
+			}
+		}
+	}
+
+	if flags.Trailers {
+		from := flags.From
+		if len(from) == 0 {
+			from = project.Tag()
+		}
+		args := []string{"log", "--reverse", "--format=%H%x1f%(trailers:key=" + backportTrailer + ",valueonly,separator=%x2C)%x1e"}
+		if len(from) > 0 {
+			args = append(args, from+"..HEAD")
+		}
+		out, err := tools.Output(ctx, tools.NewCommand("git", args...))
+		if err != nil {
+			return nil, err
+		}
+		for _, record := range strings.Split(out, "\x1e") {
+			items := strings.SplitN(strings.TrimSpace(record), "\x1f", 2)
+			if len(items) != 2 || len(strings.TrimSpace(items[1])) == 0 {
+				continue
+			}
+			log.Debug("Backport trailer", log.F("commit", items[0]).F("versions", items[1]))
+			for _, to := range strings.Split(items[1], ",") {
+				if err := add(to, items[0]); err != nil {
+					return nil, err
+				}
+			}
+		}
+	}
+	return plan, nil
+}
+
+// backportTagProject project wrapper for the release tag template of the release X.Y.0
+type backportTagProject struct {
+	*Project
+	version *semver.Version
+}
+
+func (p backportTagProject) Release() string {
+	return p.version.String()
+}
+
+func (p backportTagProject) Version() string {
+	return p.version.String()
+}
+
+func (p backportTagProject) Major() uint64 {
+	return p.version.Major()
+}
+
+func (p backportTagProject) Minor() uint64 {
+	return p.version.Minor()
+}
+
+func (p backportTagProject) Patch() uint64 {
+	return p.version.Patch()
+}
+
+func (p backportTagProject) Prerelease() string {
+	return ""
+}
+
+// backportVersion release version X.Y.0 of the minor version X.Y
+func backportVersion(minor string) (*semver.Version, error) {
+	v, err := semver.NewVersion(minor)
+	if err != nil {
+		return nil, fmt.Errorf("%w: invalid backport version %s: %v", tools.ErrInvalidInput, minor, err)
+	}
+	if v.Patch() != 0 || len(v.Prerelease()) > 0 {
+		return nil, fmt.Errorf("%w: backport version %s must be the minor version X.Y", tools.ErrInvalidInput, minor)
+	}
+	return v, nil
+}
+
+// backportBranch cherry-pick the commits to the patch branch, the branch is restored on the failure.
+// Returns true if the patch branch was created.
+func backportBranch(ctx context.Context, project *Project, version *semver.Version, commits []string, flags projectBackportFlags) (backportResult, bool) {
+	result := backportResult{Commits: commits}
+	created := false
+	fail := func(status, details string) (backportResult, bool) {
+		result.Status = status
+		result.Details = details
+		log.Error("Backport failed", log.F("branch", result.Branch).F("status", status).F("details", details))
+		return result, created
+	}
+
+	branch, err := createPatchBranchName(version, flags.Project)
+	if err != nil {
+		return fail("failed", err.Error())
+	}
+	result.Branch = branch
+
+	git := func(arg ...string) (string, error) {
+		return tools.Output(ctx, tools.NewCommand("git", arg...))
+	}
+
+	// checkout local, remote or create the branch from the release tag
+	switch {
+	case gitRefExists(ctx, "refs/heads/"+branch):
+		if _, err := git("checkout", "--quiet", branch); err != nil {
+			return fail("failed", err.Error())
+		}
+	case !flags.Project.SkipPush && gitRefExists(ctx, "refs/remotes/origin/"+branch):
+		if _, err := git("checkout", "--quiet", "-b", branch, "origin/"+branch); err != nil {
+			return fail("failed", err.Error())
+		}
+	default:
+		tag, err := tools.Template(backportTagProject{Project: project, version: version}, flags.Project.TagTemplate)
+		if err != nil {
+			return fail("failed", err.Error())
+		}
+		if !gitRefExists(ctx, "refs/tags/"+tag) {
+			return fail("failed", "release tag "+tag+" not found")
+		}
+		if _, err := git("checkout", "--quiet", "-b", branch, tag); err != nil {
+			return fail("failed", err.Error())
+		}
+		created = true
+		log.Debug("Patch branch created", log.F("branch", branch).F("tag", tag))
+	}
+	start, err := git("rev-parse", "HEAD")
+	if err != nil {
+		return fail("failed", err.Error())
+	}
+
+	// restore the branch on the failure
+	restore := func() {
+		if _, err := git("cherry-pick", "--abort"); err != nil {
+			log.Debug("No cherry-pick in progress", log.F("branch", branch))
+		}
+		if _, err := git("reset", "--quiet", "--hard", start); err != nil {
+			log.Error("Error reset patch branch", log.F("branch", branch).E(err))
+		}
+	}
+
+	for _, commit := range commits {
+		log.Info("Cherry-pick commit", log.F("branch", branch).F("commit", commit))
+		if _, err := git("cherry-pick", "-x", commit); err != nil {
+			files, _ := git("diff", "--name-only", "--diff-filter=U")
+			restore()
+			if len(files) == 0 {
+				return fail("failed", "cherry-pick "+shortHash(commit)+": "+strings.ReplaceAll(err.Error(), "\n", " "))
+			}
+			return fail("conflict", "cherry-pick "+shortHash(commit)+": "+strings.Join(strings.Split(files, "\n"), ","))
+		}
+	}
+
+	if flags.Project.SkipPush {
+		log.Info("Skip git push patch branch", log.F("branch", branch))
+	} else if err := tools.GitPush(ctx, "-u", "origin", branch); err != nil {
+		restore()
+		return fail("failed", err.Error())
+	}
+	result.Status = "done"
+	return result, created
+}
+
+func gitRefExists(ctx context.Context, ref string) bool {
+	_, err := tools.Output(ctx, tools.NewCommand("git", "rev-parse", "--verify", "-q", ref))
+	return err == nil
+}
+
+// gitAheadBehind number of commits of the left revision ahead and behind the right revision
+func gitAheadBehind(ctx context.Context, left, right string) (int, int, error) {
+	out, err := tools.Output(ctx, tools.NewCommand("git", "rev-list", "--left-right", "--count", left+"..."+right))
+	if err != nil {
+		return 0, 0, err
+	}
+	var ahead, behind int
+	if _, err := fmt.Sscanf(out, "%d %d", &ahead, &behind); err != nil {
+		return 0, 0, fmt.Errorf("error parse git rev-list output '%s': %w", out, err)
+	}
+	return ahead, behind, nil
+}
+
+func shortHash(hash string) string {
+	if len(hash) > 7 {
+		return hash[:7]
+	}
+	return hash
+}
+
+func printBackportSummary(results []backportResult) {
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "BRANCH\tCOMMITS\tSTATUS\tDETAILS")
+	for _, r := range results {
+		commits := make([]string, 0, len(r.Commits))
+		for _, c := range r.Commits {
+			commits = append(commits, shortHash(c))
+		}
+		fmt.Fprintf(w, "%s\t%s\t%s\t%s\n", r.Branch, strings.Join(commits, ","), r.Status, r.Details)
+	}
+	_ = w.Flush()
+}
@@ -0,0 +1,97 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"strconv"
+
+	"github.com/lorislab/samo/log"
+	"github.com/lorislab/samo/tools"
+	"github.com/spf13/cobra"
+)
+
+type projectCIFlags struct {
+	Project    projectFlags `mapstructure:",squash"`
+	Provider   string       `mapstructure:"ci-provider"`
+	Export     bool         `mapstructure:"ci-export"`
+	DotEnvFile string       `mapstructure:"ci-dotenv-file"`
+	EnvPrefix  string       `mapstructure:"ci-env-prefix"`
+}
+
+func createProjectCICmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "ci",
+		Short: "Show the CI build information and export the project values",
+		Long: `Show the CI build information (branch, tag, pull request, build number, commit) and export the project values
+in the native format of the CI provider:
+  github     $GITHUB_OUTPUT step outputs and $GITHUB_STEP_SUMMARY
+  gitlab     dotenv report file, use it in artifacts:reports:dotenv
+  azure      ##vso[task.setvariable] output variables
+  bitbucket  dotenv file
+  jenkins    dotenv file
+  tekton     task results in /tekton/results`,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			flags := projectCIFlags{}
+			if err := readOptions(&flags); err != nil {
+				return err
+			}
+			project, err := loadProject(cmd.Context(), flags.Project)
+			if err != nil {
+				return err
+			}
+			return projectCI(project, flags)
+		},
+		TraverseChildren: true,
+	}
+
+	addStringFlag(cmd, "ci-provider", "", "", "CI provider, one of github | gitlab | azure | bitbucket | jenkins | tekton. Default detected from the environment.")
+	addBoolFlag(cmd, "ci-export", "", false, "export the project values to the CI provider")
+	addStringFlag(cmd, "ci-dotenv-file", "", "samo.env", "dotenv file of the export")
+	addStringFlag(cmd, "ci-env-prefix", "", "SAMO_", "prefix of the exported environment variables")
+	return cmd
+}
+
+func projectCI(project *Project, flags projectCIFlags) error {
+	provider := tools.DetectCI()
+	if len(flags.Provider) > 0 {
+		p, err := tools.CIProviderByName(flags.Provider)
+		if err != nil {
+			return err
+		}
+		provider = p
+	}
+	if provider == nil {
+		return fmt.Errorf("%w: no CI provider detected, use the flag --ci-provider", tools.ErrPrecondition)
+	}
+
+	info := provider.Info()
+	if !flags.Export {
+		fmt.Printf("provider=%s\nbranch=%s\ntag=%s\npull-request=%s\nbuild-number=%s\ncommit=%s\n",
+			info.Provider, info.Branch, info.Tag, info.PullRequest, info.BuildNumber, info.Commit)
+		return nil
+	}
+
+	values := map[string]string{
+		"name":         project.Name(),
+		"version":      project.Version(),
+		"release":      project.Release(),
+		"branch":       project.Branch(),
+		"tag":          project.Tag(),
+		"hash":         project.Hash(),
+		"count":        project.Count(),
+		"patch-build":  strconv.FormatBool(project.IsPatchBuild()),
+		"pull-request": info.PullRequest,
+		"build-number": info.BuildNumber,
+	}
+	export := tools.CIExport{
+		Values:     values,
+		EnvPrefix:  flags.EnvPrefix,
+		DotEnvFile: flags.DotEnvFile,
+		Stdout:     os.Stdout,
+	}
+	if err := provider.Export(export); err != nil {
+		return err
+	}
+	log.Info("Export project values", log.F("provider", provider.Name()).F("values", len(values)))
+	return nil
+}
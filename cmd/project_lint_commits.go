@@ -0,0 +1,153 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"github.com/lorislab/samo/log"
+	"github.com/lorislab/samo/pkg/samo"
+	"github.com/lorislab/samo/tools"
+	"github.com/spf13/cobra"
+)
+
+type projectLintCommitsFlags struct {
+	Types            string `mapstructure:"lint-types"`
+	Scopes           string `mapstructure:"lint-scopes"`
+	SubjectMaxLength int    `mapstructure:"lint-subject-max-length"`
+	Base             string `mapstructure:"lint-base"`
+	MessageFile      string `mapstructure:"message-file"`
+}
+
+// lintCommit commit of the lint
+type lintCommit struct {
+	// ID short hash of the commit or the message file name
+	ID      string
+	Message string
+}
+
+func createProjectLintCommitsCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "lint-commits [range]",
+		Short: "Lint the commit messages against the conventional commits",
+		Long: `Lint the commit messages between the last tag or the --lint-base and HEAD against the conventional commits.
+The range is the git revision range, for example 1.2.0..HEAD. The merge commits are ignored.
+Commit-msg hook:
+  samo project lint-commits --message-file "$1"`,
+		Args: cobra.MaximumNArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			flags := projectLintCommitsFlags{}
+			if err := readOptions(&flags); err != nil {
+				return err
+			}
+			rng := ""
+			if len(args) > 0 {
+				rng = args[0]
+			}
+			return lintCommits(cmd.Context(), rng, flags)
+		},
+		TraverseChildren: true,
+	}
+
+	addStringFlag(cmd, "lint-types", "", strings.Join(samo.DefaultCommitTypes, ","), "comma separated list of the allowed commit types")
+	addStringFlag(cmd, "lint-scopes", "", "", "comma separated list of the allowed commit scopes, empty list allows all scopes")
+	cmd.Flags().Int("lint-subject-max-length", 100, "the maximum length of the commit header, 0 disables the check")
+	addViper(cmd, "lint-subject-max-length")
+	addStringFlag(cmd, "lint-base", "", "", "the base revision of the commits. Default the last tag")
+	addStringFlag(cmd, "message-file", "", "", "lint the commit message file of the commit-msg hook")
+	return cmd
+}
+
+func lintCommits(ctx context.Context, rng string, flags projectLintCommitsFlags) error {
+	rules := samo.CommitLintRules{
+		Types:            splitList(flags.Types),
+		Scopes:           splitList(flags.Scopes),
+		SubjectMaxLength: flags.SubjectMaxLength,
+	}
+
+	var commits []lintCommit
+	if len(flags.MessageFile) > 0 {
+		data, err := os.ReadFile(flags.MessageFile)
+		if err != nil {
+			return fmt.Errorf("%w: error read message file %s: %v", tools.ErrInvalidInput, flags.MessageFile, err)
+		}
+		msg := samo.CommitMessageFromFile(string(data))
+		// git generated merge messages
+		if strings.HasPrefix(msg, "Merge ") {
+			return nil
+		}
+		commits = append(commits, lintCommit{ID: flags.MessageFile, Message: msg})
+	} else {
+		var err error
+		if commits, err = loadLintCommits(ctx, rng, flags.Base); err != nil {
+			return err
+		}
+	}
+
+	failed := 0
+	for _, c := range commits {
+		issues := samo.LintCommit(c.Message, rules)
+		if len(issues) == 0 {
+			continue
+		}
+		failed++
+		printLintIssues(os.Stdout, c, issues)
+	}
+	if failed > 0 {
+		return fmt.Errorf("%w: %d of %d commit messages are not valid conventional commits", tools.ErrInvalidInput, failed, len(commits))
+	}
+	log.Info("Commit messages are valid.", log.F("commits", len(commits)))
+	return nil
+}
+
+// loadLintCommits commits of the range, default range is the last tag..HEAD
+func loadLintCommits(ctx context.Context, rng, base string) ([]lintCommit, error) {
+	if len(rng) == 0 {
+		if len(base) == 0 {
+			describe, err := tools.GitDescribeInfo(ctx)
+			if err != nil {
+				return nil, err
+			}
+			base = describe.Tag
+		}
+		rng = "HEAD"
+		if len(base) > 0 {
+			rng = base + "..HEAD"
+		}
+	}
+	out, err := tools.Output(ctx, tools.NewCommand("git", "log", "--no-merges", "--format=%H%x1f%B%x1e", rng))
+	if err != nil {
+		return nil, fmt.Errorf("%w: error read commits of the range %s: %v", tools.ErrInvalidInput, rng, err)
+	}
+	var commits []lintCommit
+	for _, record := range strings.Split(out, "\x1e") {
+		items := strings.SplitN(strings.TrimSpace(record), "\x1f", 2)
+		if len(items) != 2 {
+			continue
+		}
+		commits = append(commits, lintCommit{ID: shortHash(items[0]), Message: items[1]})
+	}
+	log.Debug("Lint commits", log.F("range", rng).F("commits", len(commits)))
+	return commits, nil
+}
+
+func printLintIssues(w io.Writer, c lintCommit, issues []string) {
+	header := strings.SplitN(strings.TrimSpace(c.Message), "\n", 2)[0]
+	fmt.Fprintf(w, "%s %s\n", c.ID, header)
+	for _, issue := range issues {
+		fmt.Fprintf(w, "  - %s\n", issue)
+	}
+}
+
+// splitList split the comma separated list, the empty items are removed
+func splitList(value string) []string {
+	var result []string
+	for _, item := range strings.Split(value, ",") {
+		if item = strings.TrimSpace(item); len(item) > 0 {
+			result = append(result, item)
+		}
+	}
+	return result
+}
@@ -11,13 +11,19 @@ func createProjectNameCmd() *cobra.Command {
 		Use:   "name",
 		Short: "Show the project name",
 		Long:  `Tasks to show the maven project name`,
-		Run: func(cmd *cobra.Command, args []string) {
+		RunE: func(cmd *cobra.Command, args []string) error {
 
 			flags := projectFlags{}
-			readOptions(&flags)
-			project := loadProject(flags)
+			if err := readOptions(&flags); err != nil {
+				return err
+			}
+			project, err := loadProject(cmd.Context(), flags)
+			if err != nil {
+				return err
+			}
 
 			fmt.Printf("%s\n", project.Name())
+			return nil
 		},
 		TraverseChildren: true,
 	}
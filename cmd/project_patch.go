@@ -1,6 +1,9 @@
 package cmd
 
 import (
+	"context"
+	"fmt"
+
 	"github.com/lorislab/samo/log"
 	"github.com/lorislab/samo/tools"
 	"github.com/spf13/cobra"
@@ -16,12 +19,17 @@ func createProjectPatchCmd() *cobra.Command {
 		Use:   "patch",
 		Short: "Create patch of the project release",
 		Long:  `Create patch of the project release`,
-		Run: func(cmd *cobra.Command, args []string) {
+		RunE: func(cmd *cobra.Command, args []string) error {
 			flags := projectPatchFlags{}
-			readOptions(&flags)
-			project := loadProject(flags.Project)
+			if err := readOptions(&flags); err != nil {
+				return err
+			}
+			project, err := loadProject(cmd.Context(), flags.Project)
+			if err != nil {
+				return err
+			}
 
-			patch(project, flags)
+			return patch(cmd.Context(), project, flags)
 		},
 		TraverseChildren: true,
 	}
@@ -32,22 +40,33 @@ func createProjectPatchCmd() *cobra.Command {
 }
 
 // CreatePatch create patch fo the project
-func patch(project *Project, flags projectPatchFlags) {
+func patch(ctx context.Context, project *Project, flags projectPatchFlags) error {
 
-	tagVer := tools.CreateSemVer(flags.Tag)
+	tagVer, err := tools.CreateSemVer(flags.Tag)
+	if err != nil {
+		return err
+	}
 	if tagVer.Patch() != 0 || len(tagVer.Prerelease()) > 0 {
-		log.Fatal("Can not created patch-branch from the patch tag!", log.F("tag", tagVer.Original()))
+		return fmt.Errorf("%w: can not created patch-branch from the patch tag %s", tools.ErrPrecondition, tagVer.Original())
 	}
 
-	branch := createPatchBranchName(tagVer, flags.Project)
-	tools.Git("checkout", "-b", branch, flags.Tag)
+	branch, err := createPatchBranchName(tagVer, flags.Project)
+	if err != nil {
+		return err
+	}
+	if err := tools.Git(ctx, "checkout", "-b", branch, flags.Tag); err != nil {
+		return err
+	}
 	log.Debug("Patch branch created", log.F("branch", branch))
 
 	// push changes
 	if flags.Project.SkipPush {
 		log.Info("Skip git push patch branch", log.F("branch", branch))
 	} else {
-		tools.Git("push", "-u", "origin", branch)
+		if err := tools.GitPush(ctx, "-u", "origin", branch); err != nil {
+			return err
+		}
 	}
 	log.Info("New patch branch created.", log.F("branch", branch))
+	return nil
 }
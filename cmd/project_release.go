@@ -1,16 +1,24 @@
 package cmd
 
 import (
+	"context"
+	"fmt"
+
 	"github.com/lorislab/samo/log"
 	"github.com/lorislab/samo/tools"
 	"github.com/spf13/cobra"
 )
 
 type projectReleaseFlags struct {
-	Project         projectFlags `mapstructure:",squash"`
-	MessageTemplate string       `mapstructure:"release-message-template"`
-	TagTemplate     string       `mapstructure:"release-tag-template"`
-	Revision        string       `mapstructure:"release-revision"`
+	Project         projectFlags      `mapstructure:",squash"`
+	MessageTemplate string            `mapstructure:"release-message-template"`
+	Revision        string            `mapstructure:"release-revision"`
+	Sign            string            `mapstructure:"release-sign"`
+	SignKey         string            `mapstructure:"release-sign-key"`
+	Forge           forgeFlags        `mapstructure:",squash"`
+	Checks          releaseCheckFlags `mapstructure:",squash"`
+	MergeBack       bool              `mapstructure:"release-merge-back"`
+	MergeBranch     string            `mapstructure:"release-merge-branch"`
 }
 
 func createProjectReleaseCmd() *cobra.Command {
@@ -18,44 +26,104 @@ func createProjectReleaseCmd() *cobra.Command {
 		Use:   "release",
 		Short: "Create release of the current project and state",
 		Long:  `Create release of the current project and state`,
-		Run: func(cmd *cobra.Command, args []string) {
+		RunE: func(cmd *cobra.Command, args []string) error {
 			flags := projectReleaseFlags{}
-			readOptions(&flags)
-			project := loadProject(flags.Project)
-			release(project, flags)
+			if err := readOptions(&flags); err != nil {
+				return err
+			}
+			project, err := loadProject(cmd.Context(), flags.Project)
+			if err != nil {
+				return err
+			}
+			return release(cmd.Context(), project, flags)
 		},
 		TraverseChildren: true,
 	}
 	addStringFlag(cmd, "release-revision", "", "", `optional release revision (git commit hash)`)
 	addStringFlag(cmd, "release-message-template", "", "{{ .Release }}", `the annotated tag message template.
 	Values: `+templateValues)
-	addStringFlag(cmd, "release-tag-template", "", "{{ .Release }}", `the release tag template. 
-	Values: `+templateValues)
+	addStringFlag(cmd, "release-sign", "", "", `sign the release tag, one of default | gpg | ssh.
+	default  git configuration user.signingkey and gpg.format
+	gpg      GPG key id of the flag --release-sign-key or the default GPG key
+	ssh      SSH signing key file of the flag --release-sign-key`)
+	addStringFlag(cmd, "release-sign-key", "", "", "the release tag signing key, GPG key id or SSH key file")
+	addForgeFlags(cmd)
+	addReleaseCheckFlags(cmd)
+	addBoolFlag(cmd, "release-merge-back", "", false, "merge the release tag of the release branch back to the --release-merge-branch")
+	addStringFlag(cmd, "release-merge-branch", "", "main", "the main branch of the merge of the release tag")
 
 	return cmd
 }
 
 // CreateRelease create project release
-func release(pro *Project, flags projectReleaseFlags) {
+func release(ctx context.Context, pro *Project, flags projectReleaseFlags) error {
 
 	if pro.Count() == "0" {
-		log.Fatal("Can not created release. No new commits for new release!",
-			log.Fields{"version": pro.Version(), "hash": pro.Hash(), "count": pro.Count(), "tag": pro.Tag()})
+		return fmt.Errorf("%w: can not created release, no new commits for new release (version: %s, hash: %s, tag: %s)",
+			tools.ErrPrecondition, pro.Version(), pro.Hash(), pro.Tag())
 	}
 
-	tag := tools.Template(pro, flags.TagTemplate)
-	msg := tools.Template(pro, flags.MessageTemplate)
-	cmd := []string{"tag", "-a", tag, "-m", msg}
+	tag, err := tools.Template(pro, flags.Project.TagTemplate)
+	if err != nil {
+		return err
+	}
+	if err := checkRelease(ctx, pro, tag, flags); err != nil {
+		return err
+	}
+	msg, err := tools.Template(pro, flags.MessageTemplate)
+	if err != nil {
+		return err
+	}
+	cmd, err := releaseTagArgs(flags.Sign, flags.SignKey)
+	if err != nil {
+		return err
+	}
+	cmd = append(cmd, tag, "-m", msg)
 	if len(flags.Revision) > 0 {
 		cmd = append(cmd, flags.Revision)
 	}
-	tools.Git(cmd...)
+	if err := tools.Git(ctx, cmd...); err != nil {
+		return err
+	}
 
 	// push project to remote repository
 	if flags.Project.SkipPush {
 		log.Info("Skip git push for project release", log.F("version", tag))
 	} else {
-		tools.Git("push", "--tags")
+		if err := tools.GitPush(ctx, "--tags"); err != nil {
+			return err
+		}
+		// create release object in the forge
+		if err := forgeRelease(ctx, pro, tag, flags.Forge); err != nil {
+			return err
+		}
+	}
+	if pro.IsReleaseBranch() && flags.MergeBack {
+		if err := releaseMergeBack(ctx, pro, tag, flags); err != nil {
+			return err
+		}
 	}
 	log.Info("New release created.", log.F("version", tag))
+	return nil
+}
+
+// releaseTagArgs git arguments of the annotated or signed release tag
+func releaseTagArgs(sign, key string) ([]string, error) {
+	switch sign {
+	case "":
+		return []string{"tag", "-a"}, nil
+	case "default":
+		return []string{"tag", "-s"}, nil
+	case "gpg":
+		if len(key) == 0 {
+			return []string{"-c", "gpg.format=openpgp", "tag", "-s"}, nil
+		}
+		return []string{"-c", "gpg.format=openpgp", "tag", "-u", key}, nil
+	case "ssh":
+		if len(key) == 0 {
+			return nil, fmt.Errorf("%w: flag --release-sign-key is mandatory for the ssh signing", tools.ErrInvalidInput)
+		}
+		return []string{"-c", "gpg.format=ssh", "-c", "user.signingkey=" + key, "tag", "-s"}, nil
+	}
+	return nil, fmt.Errorf("%w: not supported release sign type %s, one of default | gpg | ssh", tools.ErrInvalidInput, sign)
 }
@@ -0,0 +1,139 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/lorislab/samo/log"
+	"github.com/lorislab/samo/pkg/samo"
+	"github.com/lorislab/samo/tools"
+	"github.com/spf13/cobra"
+)
+
+func createProjectReleaseBranchCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "release-branch",
+		Short: "Create release branch of the next release",
+		Long: `Create release branch of the next release from the main line, for example release/1.3 for the next release 1.3.0.
+The release branch has the release candidate versions 1.3.0-rc.N and the release command on the branch creates the tag 1.3.0.
+The main line continues with the next minor version 1.4.0.`,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			flags := projectFlags{}
+			if err := readOptions(&flags); err != nil {
+				return err
+			}
+			project, err := loadProject(cmd.Context(), flags)
+			if err != nil {
+				return err
+			}
+			return releaseBranch(cmd.Context(), project, flags)
+		},
+		TraverseChildren: true,
+	}
+	return cmd
+}
+
+func releaseBranch(ctx context.Context, project *Project, flags projectFlags) error {
+	if len(flags.ReleaseBranch) == 0 {
+		return fmt.Errorf("%w: release branches are disabled, missing --release-branch-template", tools.ErrInvalidInput)
+	}
+	if project.IsPatchBuild() || project.IsReleaseBranch() {
+		return fmt.Errorf("%w: can not created release branch from the patch or release branch %s", tools.ErrPrecondition, project.Branch())
+	}
+
+	ver, err := tools.CreateSemVer(project.Release())
+	if err != nil {
+		return err
+	}
+	branch, err := samo.ReleaseBranchName(ver, flags.ReleaseBranch)
+	if err != nil {
+		return err
+	}
+	if gitRefExists(ctx, "refs/heads/"+branch) || gitRefExists(ctx, "refs/remotes/origin/"+branch) {
+		return fmt.Errorf("%w: release branch %s already exists", tools.ErrPrecondition, branch)
+	}
+	if err := tools.Git(ctx, "checkout", "-b", branch); err != nil {
+		return err
+	}
+	log.Debug("Release branch created", log.F("branch", branch))
+
+	// push changes
+	if flags.SkipPush {
+		log.Info("Skip git push release branch", log.F("branch", branch))
+	} else {
+		if err := tools.GitPush(ctx, "-u", "origin", branch); err != nil {
+			return err
+		}
+	}
+	next := ver.IncMinor()
+	log.Info("New release branch created.", log.F("branch", branch).F("release", ver.String()).F("next", next.String()))
+	return nil
+}
+
+// releaseMergeBack merge the release tag of the release branch to the main branch
+func releaseMergeBack(ctx context.Context, project *Project, tag string, flags projectReleaseFlags) error {
+	git := func(arg ...string) error {
+		_, err := tools.Output(ctx, tools.NewCommand("git", arg...))
+		return err
+	}
+	// the merge branch is pushed, fetch the latest state of the remote branch
+	if !flags.Project.SkipPush {
+		fetch := tools.NewCommand("git", "fetch", "--quiet", "origin", flags.MergeBranch)
+		fetch.Retry = true
+		if err := tools.Run(ctx, fetch); err != nil {
+			return err
+		}
+	}
+	if err := git("checkout", "--quiet", flags.MergeBranch); err != nil {
+		return fmt.Errorf("%w: checkout of the merge branch %s failed: %v", tools.ErrCommandFailed, flags.MergeBranch, err)
+	}
+	// switch back to the release branch
+	defer func() {
+		if err := git("checkout", "--quiet", project.Branch()); err != nil {
+			log.Error("Error switch back to the release branch", log.F("branch", project.Branch()).E(err))
+		}
+	}()
+
+	if !flags.Project.SkipPush {
+		if err := mergeBranchUpToDate(ctx, flags.MergeBranch); err != nil {
+			return err
+		}
+	}
+
+	if err := git("merge", "--no-ff", "-m", "Merge release "+tag+" into "+flags.MergeBranch, tag); err != nil {
+		if e := git("merge", "--abort"); e != nil {
+			log.Debug("No merge in progress", log.F("branch", flags.MergeBranch))
+		}
+		return fmt.Errorf("%w: merge of the release %s into %s failed, merge the tag manually: %v", tools.ErrCommandFailed, tag, flags.MergeBranch, err)
+	}
+
+	if flags.Project.SkipPush {
+		log.Info("Skip git push merge branch", log.F("branch", flags.MergeBranch))
+	} else {
+		if err := tools.GitPush(ctx, "origin", flags.MergeBranch); err != nil {
+			return err
+		}
+	}
+	log.Info("Release merged.", log.F("version", tag).F("branch", flags.MergeBranch))
+	return nil
+}
+
+// mergeBranchUpToDate fast-forward the checked out merge branch to the fetched remote branch.
+// The local commits of the merge branch which are not pushed fail the merge back.
+func mergeBranchUpToDate(ctx context.Context, branch string) error {
+	remote := "origin/" + branch
+	ahead, behind, err := gitAheadBehind(ctx, "HEAD", remote)
+	if err != nil {
+		return err
+	}
+	switch {
+	case ahead > 0 && behind > 0:
+		return fmt.Errorf("%w: merge branch %s and %s have diverged (%d ahead, %d behind)", tools.ErrPrecondition, branch, remote, ahead, behind)
+	case ahead > 0:
+		return fmt.Errorf("%w: merge branch %s is not pushed, %d commits ahead of %s", tools.ErrPrecondition, branch, ahead, remote)
+	case behind > 0:
+		log.Info("Fast-forward merge branch", log.F("branch", branch).F("behind", behind))
+		return tools.Git(ctx, "merge", "--ff-only", "--quiet", remote)
+	}
+	return nil
+}
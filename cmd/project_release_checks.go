@@ -0,0 +1,240 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"regexp"
+	"strings"
+
+	"github.com/Masterminds/semver/v3"
+	"github.com/lorislab/samo/log"
+	"github.com/lorislab/samo/tools"
+	"github.com/spf13/cobra"
+)
+
+type releaseCheckFlags struct {
+	SkipChecks   string `mapstructure:"release-skip-checks"`
+	Branches     string `mapstructure:"release-branches"`
+	CIStatusFile string `mapstructure:"release-ci-status-file"`
+	Remote       bool   `mapstructure:"release-check-remote"`
+	Force        bool   `mapstructure:"force"`
+}
+
+// releaseCheck precondition of the release, returns the reason of the failure or empty string
+type releaseCheck struct {
+	name  string
+	check func(ctx context.Context, project *Project, tag string, flags projectReleaseFlags) (string, error)
+}
+
+var releaseChecks = []releaseCheck{
+	{name: "clean", check: checkReleaseClean},
+	{name: "upstream", check: checkReleaseUpstream},
+	{name: "branch", check: checkReleaseBranch},
+	{name: "tag-exists", check: checkReleaseTagExists},
+	{name: "tag-greater", check: checkReleaseTagGreater},
+	{name: "ci-status", check: checkReleaseCIStatus},
+}
+
+func addReleaseCheckFlags(cmd *cobra.Command) {
+	addStringFlag(cmd, "release-skip-checks", "", "", `comma separated list of the disabled release checks, all disables all checks.
+	clean        no changes of the tracked files, the untracked files are ignored
+	upstream     HEAD is pushed and up to date with the upstream branch (only with --release-check-remote, skipped on detached HEAD)
+	branch       release branch matches the --release-branches patterns
+	tag-exists   the release tag does not exist locally, or remotely with --release-check-remote
+	tag-greater  the release version is greater than the existing tags, the patch release only the tags of the same minor version
+	ci-status    the CI status file --release-ci-status-file exists`)
+	addStringFlag(cmd, "release-branches", "", "", "comma separated list of the regular expressions of the release branches. Example: ^main$,^fix/.*")
+	addStringFlag(cmd, "release-ci-status-file", "", "", "the CI status file required for the release")
+	addBoolFlag(cmd, "release-check-remote", "", false, "the release checks fetch the origin repository, skipped with --skip-push or without the origin remote")
+	addBoolFlag(cmd, "force", "", false, "create the release even if the release checks fail")
+}
+
+// checkRelease run all enabled release checks and report the failures
+func checkRelease(ctx context.Context, project *Project, tag string, flags projectReleaseFlags) error {
+	skip := map[string]bool{}
+	for _, name := range strings.Split(flags.Checks.SkipChecks, ",") {
+		name = strings.TrimSpace(name)
+		if len(name) == 0 {
+			continue
+		}
+		if name == "all" {
+			log.Debug("All release checks are disabled")
+			return nil
+		}
+		found := false
+		for _, c := range releaseChecks {
+			found = found || c.name == name
+		}
+		if !found {
+			return fmt.Errorf("%w: unknown release check %s", tools.ErrInvalidInput, name)
+		}
+		skip[name] = true
+	}
+
+	var failures []string
+	for _, c := range releaseChecks {
+		if skip[c.name] {
+			log.Debug("Skip release check", log.F("check", c.name))
+			continue
+		}
+		reason, err := c.check(ctx, project, tag, flags)
+		if err != nil {
+			return err
+		}
+		if len(reason) > 0 {
+			log.Error("Release check failed", log.F("check", c.name).F("reason", reason))
+			failures = append(failures, c.name+": "+reason)
+			continue
+		}
+		log.Debug("Release check passed", log.F("check", c.name))
+	}
+
+	if len(failures) == 0 {
+		return nil
+	}
+	if flags.Checks.Force {
+		log.Warn("Release checks failed, the release is forced", log.F("checks", failures))
+		return nil
+	}
+	return fmt.Errorf("%w: release checks failed, use --force to override or --release-skip-checks to disable the check:\n  %s",
+		tools.ErrPrecondition, strings.Join(failures, "\n  "))
+}
+
+func checkReleaseClean(ctx context.Context, _ *Project, _ string, _ projectReleaseFlags) (string, error) {
+	out, err := tools.Output(ctx, tools.NewCommand("git", "status", "--porcelain", "--untracked-files=no"))
+	if err != nil {
+		return "", err
+	}
+	if len(strings.TrimSpace(out)) == 0 {
+		return "", nil
+	}
+	files := strings.Split(strings.TrimSpace(out), "\n")
+	return fmt.Sprintf("working tree is not clean, %d changed files: %s", len(files), strings.Join(trimList(files, 5), ", ")), nil
+}
+
+func checkReleaseUpstream(ctx context.Context, _ *Project, _ string, flags projectReleaseFlags) (string, error) {
+	if !releaseCheckRemote(ctx, flags) {
+		return "", nil
+	}
+	// detached HEAD of the CI build has no upstream branch
+	if _, err := tools.Output(ctx, tools.NewCommand("git", "symbolic-ref", "-q", "HEAD")); err != nil {
+		log.Debug("Skip release check upstream, detached HEAD")
+		return "", nil
+	}
+	upstream, err := tools.Output(ctx, tools.NewCommand("git", "rev-parse", "--abbrev-ref", "--symbolic-full-name", "@{u}"))
+	if err != nil {
+		return "current branch has no upstream branch", nil
+	}
+	fetch := tools.NewCommand("git", "fetch", "--quiet")
+	fetch.Retry = true
+	if err := tools.Run(ctx, fetch); err != nil {
+		return "", err
+	}
+	ahead, behind, err := gitAheadBehind(ctx, "HEAD", upstream)
+	if err != nil {
+		return "", err
+	}
+	switch {
+	case ahead > 0 && behind > 0:
+		return fmt.Sprintf("HEAD and %s have diverged (%d ahead, %d behind)", upstream, ahead, behind), nil
+	case ahead > 0:
+		return fmt.Sprintf("HEAD is not pushed, %d commits ahead of %s", ahead, upstream), nil
+	case behind > 0:
+		return fmt.Sprintf("HEAD is not up to date, %d commits behind %s", behind, upstream), nil
+	}
+	return "", nil
+}
+
+// releaseCheckRemote the network checks are enabled and the origin remote exists
+func releaseCheckRemote(ctx context.Context, flags projectReleaseFlags) bool {
+	if !flags.Checks.Remote || flags.Project.SkipPush {
+		return false
+	}
+	if _, err := tools.Output(ctx, tools.NewCommand("git", "remote", "get-url", "origin")); err != nil {
+		log.Debug("Skip remote release checks, no origin remote")
+		return false
+	}
+	return true
+}
+
+func checkReleaseBranch(_ context.Context, project *Project, _ string, flags projectReleaseFlags) (string, error) {
+	if len(flags.Checks.Branches) == 0 {
+		return "", nil
+	}
+	patterns := strings.Split(flags.Checks.Branches, ",")
+	for _, p := range patterns {
+		reg, err := regexp.Compile(strings.TrimSpace(p))
+		if err != nil {
+			return "", fmt.Errorf("%w: invalid release branch pattern %s: %v", tools.ErrInvalidInput, p, err)
+		}
+		if reg.MatchString(project.Branch()) {
+			return "", nil
+		}
+	}
+	return fmt.Sprintf("branch %s does not match the release branches %s", project.Branch(), flags.Checks.Branches), nil
+}
+
+func checkReleaseTagExists(ctx context.Context, _ *Project, tag string, flags projectReleaseFlags) (string, error) {
+	if _, err := tools.Output(ctx, tools.NewCommand("git", "rev-parse", "-q", "--verify", "refs/tags/"+tag)); err == nil {
+		return fmt.Sprintf("tag %s already exists locally", tag), nil
+	}
+	if !releaseCheckRemote(ctx, flags) {
+		return "", nil
+	}
+	c := tools.NewCommand("git", "ls-remote", "--tags", "origin", "refs/tags/"+tag)
+	c.Retry = true
+	out, err := tools.Output(ctx, c)
+	if err != nil {
+		return "", err
+	}
+	if len(strings.TrimSpace(out)) > 0 {
+		return fmt.Sprintf("tag %s already exists in the remote repository", tag), nil
+	}
+	return "", nil
+}
+
+func checkReleaseTagGreater(ctx context.Context, project *Project, tag string, _ projectReleaseFlags) (string, error) {
+	version, err := semver.NewVersion(tag)
+	if err != nil {
+		version, err = tools.CreateSemVer(project.Release())
+		if err != nil {
+			return "", err
+		}
+	}
+	out, err := tools.Output(ctx, tools.NewCommand("git", "tag", "--list"))
+	if err != nil {
+		return "", err
+	}
+	for _, t := range strings.Split(out, "\n") {
+		v, err := semver.NewVersion(strings.TrimSpace(t))
+		if err != nil {
+			continue
+		}
+		// the patch release of the older minor version, for example 1.2.1 with the existing 1.3.0
+		if project.IsPatchBuild() && (v.Major() != version.Major() || v.Minor() != version.Minor()) {
+			continue
+		}
+		if !version.GreaterThan(v) {
+			return fmt.Sprintf("release version %s is not greater than the existing tag %s", version.String(), t), nil
+		}
+	}
+	return "", nil
+}
+
+func checkReleaseCIStatus(_ context.Context, _ *Project, _ string, flags projectReleaseFlags) (string, error) {
+	if len(flags.Checks.CIStatusFile) == 0 {
+		return "", nil
+	}
+	if !tools.Exists(flags.Checks.CIStatusFile) {
+		return fmt.Sprintf("CI status file %s does not exist", flags.Checks.CIStatusFile), nil
+	}
+	return "", nil
+}
+
+// trimList first items of the list
+func trimList(items []string, size int) []string {
+	if len(items) <= size {
+		return items
+	}
+	return append(items[:size:size], "...")
+}
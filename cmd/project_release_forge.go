@@ -0,0 +1,121 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"path/filepath"
+	"strings"
+
+	"github.com/lorislab/samo/log"
+	"github.com/lorislab/samo/pkg/samo"
+	"github.com/lorislab/samo/tools"
+	"github.com/spf13/cobra"
+)
+
+type forgeFlags struct {
+	Kind         string `mapstructure:"release-forge"`
+	URL          string `mapstructure:"release-forge-url"`
+	Repository   string `mapstructure:"release-forge-repository"`
+	NameTemplate string `mapstructure:"release-forge-name-template"`
+	BodyTemplate string `mapstructure:"release-forge-body-template"`
+	Draft        bool   `mapstructure:"release-forge-draft"`
+	Prerelease   bool   `mapstructure:"release-forge-prerelease"`
+	Assets       string `mapstructure:"release-forge-assets"`
+}
+
+// forgeReleaseData template data of the forge release
+type forgeReleaseData struct {
+	*Project
+	// ReleaseTag the created release tag
+	ReleaseTag string
+	// Changelog generated changelog since the last tag
+	Changelog string
+}
+
+func addForgeFlags(cmd *cobra.Command) {
+	addStringFlag(cmd, "release-forge", "", "", `create the release in the forge after the tag push, one of github | gitlab | gitea.
+	The token is read from the SAMO_FORGE_TOKEN or GITHUB_TOKEN | GITLAB_TOKEN | GITEA_TOKEN environment variable`)
+	addStringFlag(cmd, "release-forge-url", "", "", "the forge REST API URL. Default https://api.github.com or https://gitlab.com/api/v4")
+	addStringFlag(cmd, "release-forge-repository", "", "", "the forge repository owner/name. Default from the git remote origin URL")
+	addStringFlag(cmd, "release-forge-name-template", "", "{{ .ReleaseTag }}", `the forge release name template.
+	Values: ReleaseTag,Changelog,`+templateValues)
+	addStringFlag(cmd, "release-forge-body-template", "", "{{ .Changelog }}", `the forge release body template. Default generated changelog since the last tag.
+	Values: ReleaseTag,Changelog,`+templateValues)
+	addBoolFlag(cmd, "release-forge-draft", "", false, "create draft release")
+	addBoolFlag(cmd, "release-forge-prerelease", "", false, "mark the release as prerelease")
+	addStringFlag(cmd, "release-forge-assets", "", "", "comma separated list of the file patterns uploaded to the release. Example: *.tgz,checksums.txt")
+}
+
+// forgeRelease create the release object in the forge
+func forgeRelease(ctx context.Context, project *Project, tag string, flags forgeFlags) error {
+	if len(flags.Kind) == 0 {
+		return nil
+	}
+
+	repo := flags.Repository
+	if len(repo) == 0 {
+		repo = samo.ForgeRepository(project.Source())
+	}
+	forge, err := samo.NewForge(samo.ForgeOptions{
+		Kind:       flags.Kind,
+		URL:        flags.URL,
+		Repository: repo,
+		Token:      samo.ForgeToken(flags.Kind),
+	})
+	if err != nil {
+		return err
+	}
+
+	commits, err := tools.GitLogMessages(ctx, project.Tag(), tag)
+	if err != nil {
+		return err
+	}
+	data := forgeReleaseData{Project: project, ReleaseTag: tag, Changelog: samo.Changelog(commits)}
+	name, err := tools.Template(data, flags.NameTemplate)
+	if err != nil {
+		return err
+	}
+	body, err := tools.Template(data, flags.BodyTemplate)
+	if err != nil {
+		return err
+	}
+	assets, err := forgeAssets(flags.Assets)
+	if err != nil {
+		return err
+	}
+
+	log.Info("Create forge release", log.F("forge", flags.Kind).F("repository", repo).F("tag", tag).F("assets", assets))
+	u, err := forge.CreateRelease(ctx, samo.ForgeRelease{
+		Tag:        tag,
+		Name:       name,
+		Body:       body,
+		Draft:      flags.Draft,
+		Prerelease: flags.Prerelease,
+		Assets:     assets,
+	})
+	if err != nil {
+		return err
+	}
+	log.Info("Forge release created.", log.F("url", u))
+	return nil
+}
+
+// forgeAssets resolve the file patterns of the assets
+func forgeAssets(patterns string) ([]string, error) {
+	var result []string
+	for _, pattern := range strings.Split(patterns, ",") {
+		pattern = strings.TrimSpace(pattern)
+		if len(pattern) == 0 {
+			continue
+		}
+		files, err := filepath.Glob(pattern)
+		if err != nil {
+			return nil, fmt.Errorf("%w: invalid release asset pattern %s: %v", tools.ErrInvalidInput, pattern, err)
+		}
+		if len(files) == 0 {
+			return nil, fmt.Errorf("%w: no release asset found for the pattern %s", tools.ErrPrecondition, pattern)
+		}
+		result = append(result, files...)
+	}
+	return result, nil
+}
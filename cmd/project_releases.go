@@ -0,0 +1,212 @@
+package cmd
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/Masterminds/semver/v3"
+	"github.com/lorislab/samo/tools"
+	"github.com/spf13/cobra"
+)
+
+type projectReleasesFlags struct {
+	TagPrefix      string `mapstructure:"releases-tag-prefix"`
+	Since          string `mapstructure:"since"`
+	Major          string `mapstructure:"major"`
+	LatestPerMinor bool   `mapstructure:"latest-per-minor"`
+	Format         string `mapstructure:"releases-format"`
+}
+
+// projectRelease release tag of the project
+type projectRelease struct {
+	Tag     string    `json:"tag"`
+	Version string    `json:"version"`
+	Date    time.Time `json:"date"`
+	Commit  string    `json:"commit"`
+	Tagger  string    `json:"tagger,omitempty"`
+	Message string    `json:"message,omitempty"`
+	Patch   bool      `json:"patch"`
+	// Commits number of commits since the previous release
+	Commits int `json:"commits"`
+
+	version *semver.Version
+}
+
+func createProjectReleasesCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "releases",
+		Short: "List the releases of the project",
+		Long: `List the semver tags of the project in the version order with the date, commit, tagger, message,
+patch line and the number of commits since the previous release.
+Example:
+  samo project releases --major 2 --latest-per-minor --releases-format markdown`,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			flags := projectReleasesFlags{}
+			if err := readOptions(&flags); err != nil {
+				return err
+			}
+			return releases(cmd.Context(), flags)
+		},
+		TraverseChildren: true,
+	}
+
+	addStringFlag(cmd, "releases-tag-prefix", "", "", "the prefix of the release tags, for example v")
+	addStringFlag(cmd, "since", "", "", "list releases since the version or the date (2006-01-02)")
+	addStringFlag(cmd, "major", "", "", "list releases of the major version")
+	addBoolFlag(cmd, "latest-per-minor", "", false, "list only the latest release of each minor version")
+	addStringFlag(cmd, "releases-format", "", "table", "the output format, one of table | json | markdown")
+	return cmd
+}
+
+func releases(ctx context.Context, flags projectReleasesFlags) error {
+	list, err := loadReleases(ctx, flags.TagPrefix)
+	if err != nil {
+		return err
+	}
+	list, err = filterReleases(list, flags)
+	if err != nil {
+		return err
+	}
+	return printReleases(os.Stdout, list, flags.Format)
+}
+
+// loadReleases semver tags of the repository in the version order
+func loadReleases(ctx context.Context, prefix string) ([]*projectRelease, error) {
+	format := strings.Join([]string{"%(refname:short)", "%(objectname)", "%(*objectname)", "%(creatordate:iso-strict)", "%(taggername) %(taggeremail)", "%(contents:subject)"}, "%1f") + "%1e"
+	out, err := tools.Output(ctx, tools.NewCommand("git", "for-each-ref", "--format="+format, "refs/tags/"+prefix+"*"))
+	if err != nil {
+		return nil, err
+	}
+
+	var list []*projectRelease
+	for _, record := range strings.Split(out, "\x1e") {
+		items := strings.Split(strings.TrimSpace(record), "\x1f")
+		if len(items) != 6 {
+			continue
+		}
+		ver, err := semver.StrictNewVersion(strings.TrimPrefix(items[0], prefix))
+		if err != nil {
+			continue
+		}
+		date, err := time.Parse(time.RFC3339, items[3])
+		if err != nil {
+			return nil, fmt.Errorf("error parse date of the tag %s: %w", items[0], err)
+		}
+		// the commit of the annotated tag is the dereferenced object
+		commit := items[2]
+		if len(commit) == 0 {
+			commit = items[1]
+		}
+		list = append(list, &projectRelease{
+			Tag:     items[0],
+			Version: ver.String(),
+			Date:    date,
+			Commit:  commit,
+			Tagger:  strings.TrimSpace(items[4]),
+			Message: items[5],
+			Patch:   ver.Patch() > 0,
+			version: ver,
+		})
+	}
+	sort.SliceStable(list, func(i, j int) bool {
+		return list[i].version.LessThan(list[j].version)
+	})
+
+	for i, r := range list {
+		rev := r.Commit
+		if i > 0 {
+			rev = list[i-1].Commit + ".." + r.Commit
+		}
+		count, err := tools.Output(ctx, tools.NewCommand("git", "rev-list", "--count", rev))
+		if err != nil {
+			return nil, err
+		}
+		if r.Commits, err = strconv.Atoi(count); err != nil {
+			return nil, fmt.Errorf("error parse commit count %s of the tag %s: %w", count, r.Tag, err)
+		}
+	}
+	return list, nil
+}
+
+func filterReleases(list []*projectRelease, flags projectReleasesFlags) ([]*projectRelease, error) {
+	var filters []func(r *projectRelease) bool
+
+	if len(flags.Since) > 0 {
+		// the date is valid loose semver version, check the date first
+		if date, err := time.ParseInLocation("2006-01-02", flags.Since, time.Local); err == nil {
+			filters = append(filters, func(r *projectRelease) bool { return !r.Date.Before(date) })
+		} else if ver, err := semver.NewVersion(flags.Since); err == nil {
+			filters = append(filters, func(r *projectRelease) bool { return !r.version.LessThan(ver) })
+		} else {
+			return nil, fmt.Errorf("%w: invalid --since value %s, expected version or date 2006-01-02", tools.ErrInvalidInput, flags.Since)
+		}
+	}
+	if len(flags.Major) > 0 {
+		major, err := strconv.ParseUint(flags.Major, 10, 64)
+		if err != nil {
+			return nil, fmt.Errorf("%w: invalid --major value %s", tools.ErrInvalidInput, flags.Major)
+		}
+		filters = append(filters, func(r *projectRelease) bool { return r.version.Major() == major })
+	}
+
+	var result []*projectRelease
+	for _, r := range list {
+		match := true
+		for _, f := range filters {
+			match = match && f(r)
+		}
+		if match {
+			result = append(result, r)
+		}
+	}
+
+	if flags.LatestPerMinor {
+		latest := result[:0]
+		for i, r := range result {
+			if i+1 < len(result) && result[i+1].version.Major() == r.version.Major() && result[i+1].version.Minor() == r.version.Minor() {
+				continue
+			}
+			latest = append(latest, r)
+		}
+		result = latest
+	}
+	return result, nil
+}
+
+func printReleases(w io.Writer, list []*projectRelease, format string) error {
+	switch format {
+	case "json":
+		if list == nil {
+			list = []*projectRelease{}
+		}
+		enc := json.NewEncoder(w)
+		enc.SetIndent("", "  ")
+		return enc.Encode(list)
+	case "table":
+		tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+		fmt.Fprintln(tw, "TAG\tDATE\tCOMMIT\tPATCH\tCOMMITS\tTAGGER\tMESSAGE")
+		for _, r := range list {
+			fmt.Fprintf(tw, "%s\t%s\t%s\t%t\t%d\t%s\t%s\n", r.Tag, r.Date.Format("2006-01-02"), shortHash(r.Commit), r.Patch, r.Commits, r.Tagger, r.Message)
+		}
+		return tw.Flush()
+	case "markdown":
+		var sb strings.Builder
+		sb.WriteString("| Tag | Date | Commit | Patch | Commits | Tagger | Message |\n")
+		sb.WriteString("|-----|------|--------|-------|---------|--------|---------|\n")
+		escape := strings.NewReplacer("|", `\|`)
+		for _, r := range list {
+			sb.WriteString(fmt.Sprintf("| %s | %s | `%s` | %t | %d | %s | %s |\n", r.Tag, r.Date.Format("2006-01-02"), shortHash(r.Commit), r.Patch, r.Commits, escape.Replace(r.Tagger), escape.Replace(r.Message)))
+		}
+		_, err := io.WriteString(w, sb.String())
+		return err
+	}
+	return fmt.Errorf("%w: not supported releases format %s, one of table | json | markdown", tools.ErrInvalidInput, format)
+}
@@ -0,0 +1,68 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/lorislab/samo/log"
+	"github.com/lorislab/samo/tools"
+	"github.com/spf13/cobra"
+)
+
+func createProjectVerifyTagCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "verify-tag [tag]",
+		Short: "Verify the signature of the release tag",
+		Long: `Verify the signature of the tag. Default tag is the tag at HEAD.
+The SSH signatures are checked against the allowed signers file --verify-allowed-signers.`,
+		Args: cobra.MaximumNArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			flags := projectFlags{}
+			if err := readOptions(&flags); err != nil {
+				return err
+			}
+			if len(args) > 0 {
+				return verifyTag(cmd.Context(), args[0], flags)
+			}
+			project, err := loadProject(cmd.Context(), flags)
+			if err != nil {
+				return err
+			}
+			tag, err := headTag(project)
+			if err != nil {
+				return err
+			}
+			return verifyTag(cmd.Context(), tag, flags)
+		},
+		TraverseChildren: true,
+	}
+	return cmd
+}
+
+// headTag tag at HEAD
+func headTag(project *Project) (string, error) {
+	if project.Count() != "0" || len(project.Tag()) == 0 {
+		return "", fmt.Errorf("%w: missing tag on current commit (hash: %s, count: %s, tag: %s)", tools.ErrNoTag, project.Hash(), project.Count(), project.Tag())
+	}
+	return project.Tag(), nil
+}
+
+func verifyTag(ctx context.Context, tag string, flags projectFlags) error {
+	if err := tools.GitVerifyTag(ctx, tag, flags.AllowedSigners); err != nil {
+		return err
+	}
+	log.Info("Tag signature is valid.", log.F("tag", tag))
+	return nil
+}
+
+// verifyReleaseTag verify the signature of the tag at HEAD if it is required for the release
+func verifyReleaseTag(ctx context.Context, project *Project, flags projectFlags) error {
+	if !flags.VerifyTag {
+		return nil
+	}
+	tag, err := headTag(project)
+	if err != nil {
+		return err
+	}
+	return verifyTag(ctx, tag, flags)
+}
@@ -1,14 +1,19 @@
 package cmd
 
 import (
+	"encoding/json"
 	"fmt"
+	"os"
 
+	"github.com/lorislab/samo/tools"
 	"github.com/spf13/cobra"
 )
 
 type projectVersionFlags struct {
-	Project projectFlags `mapstructure:",squash"`
-	Version string       `mapstructure:"version"`
+	Project       projectFlags `mapstructure:",squash"`
+	Version       string       `mapstructure:"version"`
+	Explain       bool         `mapstructure:"explain"`
+	ExplainFormat string       `mapstructure:"explain-format"`
 }
 
 func createProjectVersionCmd() *cobra.Command {
@@ -21,12 +26,22 @@ func createProjectVersionCmd() *cobra.Command {
 Version types:
   version  current version base on the template 'version-template'. 
            Default template: {{ .Version }}-rc.{{ .Count }}
-  release  release/final version of the project`,
-		Run: func(cmd *cobra.Command, args []string) {
+  release  release/final version of the project
+
+The flag --explain prints the decision trace of the version calculation.`,
+		RunE: func(cmd *cobra.Command, args []string) error {
 
 			flags := projectVersionFlags{}
-			readOptions(&flags)
-			project := loadProject(flags.Project)
+			if err := readOptions(&flags); err != nil {
+				return err
+			}
+			project, err := loadProject(cmd.Context(), flags.Project)
+			if err != nil {
+				return err
+			}
+			if flags.Explain {
+				return explainVersion(project, flags.ExplainFormat)
+			}
 			version := "?"
 			switch flags.Version {
 			case "version":
@@ -35,10 +50,26 @@ Version types:
 				version = project.Release()
 			}
 			fmt.Printf("%s\n", version)
+			return nil
 		},
 		TraverseChildren: true,
 	}
 
 	addStringFlag(cmd, "version", "", "version", "project version type, one of version | release ")
+	addBoolFlag(cmd, "explain", "", false, "explain the calculation of the version")
+	addStringFlag(cmd, "explain-format", "", "text", "the format of the explanation, one of text | json")
 	return cmd
 }
+
+func explainVersion(project *Project, format string) error {
+	explain := project.Explain()
+	switch format {
+	case "text":
+		return explain.WriteText(os.Stdout)
+	case "json":
+		enc := json.NewEncoder(os.Stdout)
+		enc.SetIndent("", "  ")
+		return enc.Encode(explain)
+	}
+	return fmt.Errorf("%w: not supported explain format %s, one of text | json", tools.ErrInvalidInput, format)
+}
@@ -0,0 +1,107 @@
+package cmd
+
+import (
+	"context"
+
+	"github.com/lorislab/samo/log"
+	"github.com/lorislab/samo/pkg/samo"
+	"github.com/spf13/cobra"
+)
+
+type dockerAuthFlags struct {
+	Username         string `mapstructure:"docker-username"`
+	PasswordFile     string `mapstructure:"docker-password-file"`
+	CredentialHelper string `mapstructure:"docker-credential-helper"`
+}
+
+type dockerReleaseAuthFlags struct {
+	Username         string `mapstructure:"docker-release-username"`
+	PasswordFile     string `mapstructure:"docker-release-password-file"`
+	CredentialHelper string `mapstructure:"docker-release-credential-helper"`
+}
+
+type helmRegistryAuthFlags struct {
+	Username         string `mapstructure:"helm-registry-username"`
+	PasswordFile     string `mapstructure:"helm-registry-password-file"`
+	CredentialHelper string `mapstructure:"helm-registry-credential-helper"`
+}
+
+// registryKind the client of the registry login
+type registryKind string
+
+const (
+	registryDocker registryKind = "docker"
+	registryHelm   registryKind = "helm"
+)
+
+// registryConfig isolated registry config of the run, created by the first login
+var registryConfig *samo.RegistryConfig
+
+// registryLogins the username of the last login of the run, key is the client and the host.
+// The config has one login per host, the release registry credentials replace the build registry credentials.
+var registryLogins = map[string]string{}
+
+func addRegistryAuthFlags(cmd *cobra.Command, prefix, env, registry string) {
+	addStringFlag(cmd, prefix+"-username", "", "", "the username of the "+registry+". Env: "+env+"_USERNAME")
+	addStringFlag(cmd, prefix+"-password-file", "", "", "the file with the password of the "+registry+". Env: "+env+"_PASSWORD or "+env+"_PASSWORD_FILE")
+	addStringFlag(cmd, prefix+"-credential-helper", "", "", "the docker credential helper of the "+registry+", for example: pass, secretservice, ecr-login")
+}
+
+func dockerAuth(registry string, flags dockerAuthFlags) samo.RegistryAuth {
+	return samo.RegistryAuth{Registry: registry, EnvPrefix: "SAMO_DOCKER", Username: flags.Username, PasswordFile: flags.PasswordFile, CredentialHelper: flags.CredentialHelper}
+}
+
+func dockerReleaseAuth(registry string, flags dockerReleaseAuthFlags) samo.RegistryAuth {
+	return samo.RegistryAuth{Registry: registry, EnvPrefix: "SAMO_DOCKER_RELEASE", Username: flags.Username, PasswordFile: flags.PasswordFile, CredentialHelper: flags.CredentialHelper}
+}
+
+func helmRegistryAuth(registry string, flags helmRegistryAuthFlags) samo.RegistryAuth {
+	return samo.RegistryAuth{Registry: registry, EnvPrefix: "SAMO_HELM_REGISTRY", Username: flags.Username, PasswordFile: flags.PasswordFile, CredentialHelper: flags.CredentialHelper}
+}
+
+// registryLogin login to the registry if the credentials are configured.
+// Without the credentials samo uses the existing login of the docker or helm client.
+func registryLogin(ctx context.Context, kind registryKind, auth samo.RegistryAuth) error {
+	credentials, err := auth.Credentials(ctx)
+	if err != nil || credentials == nil {
+		return err
+	}
+	return registryLoginCredentials(ctx, kind, *credentials)
+}
+
+func registryLoginCredentials(ctx context.Context, kind registryKind, credentials samo.RegistryCredentials) error {
+	key := string(kind) + "|" + credentials.Host
+	if username, exists := registryLogins[key]; exists && username == credentials.Username {
+		return nil
+	}
+	if registryConfig == nil {
+		rc, err := samo.NewRegistryConfig()
+		if err != nil {
+			return err
+		}
+		registryConfig = rc
+	}
+
+	log.Info("Registry login", log.F("client", kind).F("registry", credentials.Host).F("username", credentials.Username))
+	cmd := samo.DockerLogin(credentials)
+	if kind == registryHelm {
+		cmd = samo.HelmRegistryLogin(credentials)
+	}
+	if err := samo.Run(ctx, cmd); err != nil {
+		return err
+	}
+	registryLogins[key] = credentials.Username
+	return nil
+}
+
+// closeRegistryConfig remove the isolated registry config with the credentials of the run
+func closeRegistryConfig() {
+	if registryConfig == nil {
+		return
+	}
+	if err := registryConfig.Close(); err != nil {
+		log.Warn("Error remove registry config", log.F("dir", registryConfig.Dir).E(err))
+	}
+	registryConfig = nil
+	registryLogins = map[string]string{}
+}
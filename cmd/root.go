@@ -1,10 +1,16 @@
 package cmd
 
 import (
+	"context"
+	"fmt"
+	"os"
+	"os/signal"
 	"strings"
+	"syscall"
+	"time"
 
 	"github.com/lorislab/samo/log"
-	"github.com/mitchellh/go-homedir"
+	"github.com/lorislab/samo/tools"
 	"github.com/spf13/cobra"
 	"github.com/spf13/viper"
 )
@@ -12,18 +18,48 @@ import (
 var (
 	// Used for flags.
 	cfgFile string
-	v       string
-	rootCmd *cobra.Command
+	// config the layered configuration of the configuration files
+	config *configuration
+	// configErr error of the configuration files, returned by the command
+	configErr error
+	v         string
+	rootCmd   *cobra.Command
 )
 
 // Execute executes the root command.
 func Execute(version BuildVersion) {
 	bv = version
 
-	err := rootCmd.Execute()
+	// cancel the running commands on SIGINT or SIGTERM
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	err := rootCmd.ExecuteContext(ctx)
+	stop()
+	closeRegistryConfig()
 	if err != nil {
-		log.Fatal("error execute command", log.E(err))
+		code := exitCode(err)
+		log.Error("error execute command", log.E(err).F("exit-code", code))
+		log.Close()
+		os.Exit(code)
 	}
+	log.Close()
+}
+
+// addSecrets register the values of the secret flags and environment variables for the redaction
+func addSecrets() {
+	log.AddSecretEnv()
+	for _, key := range viper.AllKeys() {
+		if log.IsSecretName(key) {
+			log.AddSecret(viper.GetString(key))
+		}
+	}
+}
+
+// correlationID the correlation ID of the SAMO_CORRELATION_ID environment variable or new random ID
+func correlationID() string {
+	if id := os.Getenv("SAMO_CORRELATION_ID"); len(id) > 0 {
+		return id
+	}
+	return log.NewCorrelationID()
 }
 
 func init() {
@@ -33,50 +69,93 @@ func init() {
 		Short: "samo build and release tool",
 		Long:  `Samo is semantic version release utility for git project.`,
 		PersistentPreRunE: func(cmd *cobra.Command, args []string) error {
-			log.SetLevel(v)
+			if _, ignore := cmd.Annotations[configErrorsAnnotation]; configErr != nil && !ignore {
+				return configErr
+			}
+			if err := log.Configure(viper.GetString("log-format"), viper.GetString("log-file")); err != nil {
+				return fmt.Errorf("%w: %v", tools.ErrInvalidInput, err)
+			}
+			log.SetCorrelationID(correlationID())
+			addSecrets()
+			if err := log.SetLevel(v); err != nil {
+				return fmt.Errorf("%w: error parse log level: %v", tools.ErrInvalidInput, err)
+			}
+			if config != nil {
+				for _, file := range config.Files {
+					log.Info("Using config", log.F("file", file))
+				}
+			}
+			if err := cmd.ValidateRequiredFlags(); err != nil {
+				return fmt.Errorf("%w: %v", tools.ErrInvalidInput, err)
+			}
+			if err := setExecOptions(cmd); err != nil {
+				return err
+			}
+			commandStarted = true
 			return nil
 		},
 		TraverseChildren: true,
+		SilenceErrors:    true,
+		SilenceUsage:     true,
 	}
+	rootCmd.SetFlagErrorFunc(func(cmd *cobra.Command, err error) error {
+		return fmt.Errorf("%w: %v", tools.ErrInvalidInput, err)
+	})
 
 	cobra.OnInitialize(initConfig)
 
-	rootCmd.PersistentFlags().StringVar(&cfgFile, "config", "", "config file (default is .samo.yaml or $HOME/.samo.yaml)")
+	rootCmd.PersistentFlags().StringVar(&cfgFile, "config", "", "config file, merged after $HOME/.samo.yaml and .samo.yaml of the repository root")
 	rootCmd.PersistentFlags().StringVarP(&v, "verbosity", "v", log.DefaultLevel(), "Log level (debug, info, warn, error, fatal, panic)")
 
+	rootCmd.PersistentFlags().String("log-format", "console", "Log format (console, json, logfmt)")
+	rootCmd.PersistentFlags().String("log-file", "", "Log file, the log is written to the stderr and the file")
+	rootCmd.PersistentFlags().Duration("cmd-timeout", 0, "timeout of the each external command, for example 10m. Default no timeout.")
+	rootCmd.PersistentFlags().Int("retry", 0, "number of retries of the failed network commands (push, pull, imagetools, helm pull/push, git push)")
+	rootCmd.PersistentFlags().Duration("retry-delay", 2*time.Second, "delay before the first retry, doubled for each next retry")
+	rootCmd.PersistentFlags().Duration("retry-max-delay", 30*time.Second, "maximum delay between two retries")
+	for _, name := range []string{"log-format", "log-file", "cmd-timeout", "retry", "retry-delay", "retry-max-delay"} {
+		if err := viper.BindPFlag(name, rootCmd.PersistentFlags().Lookup(name)); err != nil {
+			log.Panic("bind flag", log.F("name", name).E(err))
+		}
+	}
+
 	addChildCmd(rootCmd, createVersionCmd())
-	addChildCmd(rootCmd, createProjectCmd())
+	projectCmd := createProjectCmd()
+	addChildCmd(rootCmd, projectCmd)
+	addChildCmd(rootCmd, createRunCmd(projectCmd))
+	// the config command shares the flags of all commands, it must be the last command
+	addChildCmd(rootCmd, createConfigCmd())
 }
 
-func initConfig() {
-	if cfgFile != "" {
-		// Use config file from the flag.
-		viper.SetConfigFile(cfgFile)
-	} else {
-		// Find home directory.
-		home, err := homedir.Dir()
-		if err != nil {
-			log.Fatal("error read home dir", log.E(err))
-		}
-
-		// Search config in current directory
-		viper.AddConfigPath(".")
-		// Search config in home directory with name ".samo" (without extension).
-		viper.AddConfigPath(home)
-		viper.SetConfigName(".samo")
-		viper.SetConfigType("yaml")
+// setExecOptions set the timeout and retry policy of the external commands to the context of the command
+func setExecOptions(cmd *cobra.Command) error {
+	opts := tools.ExecOptions{
+		Timeout: viper.GetDuration("cmd-timeout"),
+		Retry: tools.RetryPolicy{
+			Attempts: viper.GetInt("retry"),
+			Delay:    viper.GetDuration("retry-delay"),
+			MaxDelay: viper.GetDuration("retry-max-delay"),
+		},
+	}
+	if opts.Timeout < 0 || opts.Retry.Attempts < 0 || opts.Retry.Delay < 0 || opts.Retry.MaxDelay < 0 {
+		return fmt.Errorf("%w: negative command timeout or retry value", tools.ErrInvalidInput)
 	}
+	cmd.SetContext(tools.WithExecOptions(cmd.Context(), opts))
+	return nil
+}
 
+func initConfig() {
 	viper.SetEnvKeyReplacer(strings.NewReplacer("-", "_"))
 	viper.SetEnvPrefix("SAMO")
 	viper.AutomaticEnv()
 
-	if err := viper.ReadInConfig(); err == nil {
-		if _, ok := err.(viper.ConfigFileNotFoundError); ok {
-			log.Info("Configuration file not found", log.F("file", viper.ConfigFileUsed()), log.E(err))
-		} else {
-			log.Info("Using config", log.F("file", viper.ConfigFileUsed()))
-		}
+	config, configErr = loadConfiguration(configLayerFiles(cfgFile), func() (string, error) {
+		return tools.GitBranch(rootCmd.Context())
+	})
+	if configErr != nil {
+		return
+	}
+	if err := viper.MergeConfigMap(config.Values); err != nil {
+		configErr = fmt.Errorf("%w: error merge configuration: %v", tools.ErrInvalidInput, err)
 	}
-
 }
@@ -0,0 +1,364 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/go-viper/mapstructure/v2"
+	"github.com/lorislab/samo/log"
+	"github.com/lorislab/samo/pkg/samo"
+	"github.com/lorislab/samo/tools"
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+)
+
+type runFlags struct {
+	Project projectFlags `mapstructure:",squash"`
+	DryRun  bool         `mapstructure:"run-dry-run"`
+}
+
+// pipelineStep step of the pipeline, samo operation or shell command
+type pipelineStep struct {
+	Name  string                 `mapstructure:"name"`
+	Run   string                 `mapstructure:"run"`
+	Shell string                 `mapstructure:"shell"`
+	With  map[string]interface{} `mapstructure:"with"`
+	When  pipelineCondition      `mapstructure:"when"`
+}
+
+// pipelineCondition condition of the step, all conditions must match
+type pipelineCondition struct {
+	// Branch regular expression of the branch name
+	Branch string `mapstructure:"branch"`
+	// Patch the build is patch build
+	Patch *bool `mapstructure:"patch"`
+	// Tag the current commit is tagged
+	Tag *bool `mapstructure:"tag"`
+}
+
+// pipelineResult result of the executed step
+type pipelineResult struct {
+	Name     string
+	Status   string
+	Duration time.Duration
+}
+
+type pipelineOperation func(ctx context.Context, project *Project, with map[string]interface{}) error
+
+// pipelineOperations samo operations of the pipeline steps
+var pipelineOperations = map[string]pipelineOperation{
+	"docker build":   pipelineOp(dockerBuild),
+	"docker push":    pipelineOp(dockerPush),
+	"docker release": pipelineOp(dockerRelease),
+	"helm build":     pipelineOp(helmBuild),
+	"helm push": pipelineOp(func(ctx context.Context, project *Project, flags helmFlags) error {
+		return helmPush(ctx, project.Version(), project, flags)
+	}),
+	"helm release": pipelineOp(helmRelease),
+	"helm schema": pipelineOp(func(_ context.Context, project *Project, flags helmSchemaFlags) error {
+		return helmSchema(project, flags)
+	}),
+	"helm docs": pipelineOp(func(_ context.Context, project *Project, flags helmDocsFlags) error {
+		return helmDocs(project, flags)
+	}),
+	"release": pipelineOp(release),
+}
+
+func createRunCmd(projectCmd *cobra.Command) *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "run <pipeline>",
+		Short: "Run the pipeline",
+		Long: `Run the pipeline of the .samo.yaml configuration. The project is loaded once for all steps.
+Example:
+  pipelines:
+    build:
+      - run: docker build
+        with:
+          docker-build-push: true
+      - run: helm build
+      - name: notify
+        shell: echo "build {{ .Version }}"
+        when:
+          branch: ^main$
+          patch: false
+          tag: false
+
+Operations: ` + strings.Join(pipelineOperationNames(), ", ") + `
+Shell values: ` + templateValues,
+		Args: cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			flags := runFlags{}
+			if err := readOptions(&flags); err != nil {
+				return err
+			}
+			steps, err := loadPipeline(args[0])
+			if err != nil {
+				return err
+			}
+			project, err := loadProject(cmd.Context(), flags.Project)
+			if err != nil {
+				return err
+			}
+			return runPipeline(cmd.Context(), args[0], steps, project, flags)
+		},
+		TraverseChildren: true,
+	}
+
+	// share the project flags, the bindings of the project command are used
+	cmd.Flags().AddFlagSet(projectCmd.Flags())
+	addBoolFlag(cmd, "run-dry-run", "", false, "print the resolved steps of the pipeline and the conditions evaluated for the current commit, do not execute the steps")
+	return cmd
+}
+
+// pipelineOp create pipeline operation which reads the flags of the operation and applies the step values
+func pipelineOp[T any](op func(context.Context, *Project, T) error) pipelineOperation {
+	return func(ctx context.Context, project *Project, with map[string]interface{}) error {
+		var flags T
+		if err := readOptions(&flags); err != nil {
+			return err
+		}
+		if err := readStepOptions(&flags, with); err != nil {
+			return err
+		}
+		return op(ctx, project, flags)
+	}
+}
+
+// readStepOptions overwrite the options with the values of the step
+func readStepOptions(options interface{}, with map[string]interface{}) error {
+	if len(with) == 0 {
+		return nil
+	}
+	decoder, err := mapstructure.NewDecoder(&mapstructure.DecoderConfig{
+		Result:           options,
+		WeaklyTypedInput: true,
+		ErrorUnused:      true,
+		DecodeHook:       mapstructure.StringToTimeDurationHookFunc(),
+	})
+	if err != nil {
+		return err
+	}
+	if err := decoder.Decode(with); err != nil {
+		return fmt.Errorf("%w: error read step values: %v", tools.ErrInvalidInput, err)
+	}
+	return nil
+}
+
+func pipelineOperationNames() []string {
+	var names []string
+	for name := range pipelineOperations {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// loadPipeline load and validate the steps of the pipeline
+func loadPipeline(name string) ([]pipelineStep, error) {
+	pipelines := map[string][]pipelineStep{}
+	if err := viper.UnmarshalKey("pipelines", &pipelines); err != nil {
+		return nil, fmt.Errorf("%w: error read pipelines: %v", tools.ErrInvalidInput, err)
+	}
+	steps, exists := pipelines[strings.ToLower(name)]
+	if !exists {
+		var names []string
+		for n := range pipelines {
+			names = append(names, n)
+		}
+		sort.Strings(names)
+		return nil, fmt.Errorf("%w: pipeline %s not found, available pipelines: [%s]", tools.ErrInvalidInput, name, strings.Join(names, ","))
+	}
+
+	for i := range steps {
+		step := &steps[i]
+		if (len(step.Run) == 0) == (len(step.Shell) == 0) {
+			return nil, fmt.Errorf("%w: step %d of the pipeline %s must have run or shell", tools.ErrInvalidInput, i+1, name)
+		}
+		if len(step.Run) > 0 {
+			if _, exists := pipelineOperations[step.Run]; !exists {
+				return nil, fmt.Errorf("%w: not supported operation '%s' in the step %d of the pipeline %s", tools.ErrInvalidInput, step.Run, i+1, name)
+			}
+		}
+		if len(step.Shell) > 0 && len(step.With) > 0 {
+			return nil, fmt.Errorf("%w: shell step %d of the pipeline %s does not support values", tools.ErrInvalidInput, i+1, name)
+		}
+		if len(step.When.Branch) > 0 {
+			if _, err := regexp.Compile(step.When.Branch); err != nil {
+				return nil, fmt.Errorf("%w: invalid branch condition of the step %d of the pipeline %s: %v", tools.ErrInvalidInput, i+1, name, err)
+			}
+		}
+		if len(step.Name) == 0 {
+			step.Name = step.Run
+			if len(step.Name) == 0 {
+				step.Name = step.Shell
+			}
+		}
+	}
+	return steps, nil
+}
+
+// match check the conditions of the step
+func (c pipelineCondition) match(project *Project) bool {
+	if len(c.Branch) > 0 && !regexp.MustCompile(c.Branch).MatchString(project.Branch()) {
+		return false
+	}
+	if c.Patch != nil && *c.Patch != project.IsPatchBuild() {
+		return false
+	}
+	tagged := project.Count() == "0" && len(project.Tag()) > 0
+	if c.Tag != nil && *c.Tag != tagged {
+		return false
+	}
+	return true
+}
+
+func runPipeline(ctx context.Context, name string, steps []pipelineStep, project *Project, flags runFlags) error {
+	log.Info("Run pipeline", log.F("pipeline", name).F("steps", len(steps)).F("version", project.Version()).F("release", project.Release()))
+
+	if flags.DryRun {
+		return printPipelinePlan(os.Stdout, name, steps, project)
+	}
+
+	var results []pipelineResult
+	var failed error
+	for i, step := range steps {
+		result := pipelineResult{Name: step.Name}
+		switch {
+		case failed != nil:
+			result.Status = "not run"
+		case !step.When.match(project):
+			log.Info("Skip step", log.F("step", step.Name))
+			result.Status = "skipped"
+		default:
+			log.Info("Run step", log.F("step", step.Name).F("index", i+1))
+			start := time.Now()
+			// each step works with own copy, the release operations switch the project version
+			p := *project
+			if err := runPipelineStep(ctx, step, &p); err != nil {
+				failed = fmt.Errorf("step '%s' of the pipeline %s failed: %w", step.Name, name, err)
+				result.Status = "failed"
+			} else {
+				result.Status = "done"
+				// the next steps work with the tag created by the step, for example release -> docker release
+				reloaded, err := reloadPipelineProject(ctx, project, flags.Project)
+				if err != nil {
+					failed = fmt.Errorf("error reload project after the step '%s' of the pipeline %s: %w", step.Name, name, err)
+				} else {
+					project = reloaded
+				}
+			}
+			result.Duration = time.Since(start).Round(time.Millisecond)
+		}
+		results = append(results, result)
+	}
+
+	printPipelineSummary(name, results)
+	return failed
+}
+
+func runPipelineStep(ctx context.Context, step pipelineStep, project *Project) error {
+	if len(step.Run) > 0 {
+		return pipelineOperations[step.Run](ctx, project, step.With)
+	}
+	script, err := tools.Template(project, step.Shell)
+	if err != nil {
+		return err
+	}
+	c := samo.Cmd("sh", "-c", script)
+	c.Stdout = os.Stdout
+	return samo.Run(ctx, c)
+}
+
+// reloadPipelineProject load the project again if the step changed the tag or the commit of the project
+func reloadPipelineProject(ctx context.Context, project *Project, flags projectFlags) (*Project, error) {
+	describe, err := tools.GitDescribeInfo(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if describe.Tag == project.Tag() && describe.Count == project.Count() && describe.Hash == project.Hash() {
+		return project, nil
+	}
+	log.Info("Reload project", log.F("tag", describe.Tag).F("count", describe.Count).F("previous-tag", project.Tag()))
+	return loadProject(ctx, flags)
+}
+
+// printPipelinePlan print the resolved steps of the pipeline with the conditions, the steps are not executed.
+// The conditions are evaluated for the current project, the tags created by the steps are not known.
+func printPipelinePlan(out io.Writer, name string, steps []pipelineStep, project *Project) error {
+	w := tabwriter.NewWriter(out, 0, 0, 2, ' ', 0)
+	fmt.Fprintf(w, "PIPELINE %s (dry-run)\n", name)
+	fmt.Fprintln(w, "#\tSTEP\tRUN\tWHEN\tMATCH\tWITH")
+	for i, step := range steps {
+		run := step.Run
+		if len(run) == 0 {
+			script, err := tools.Template(project, step.Shell)
+			if err != nil {
+				return fmt.Errorf("%w: error resolve shell of the step '%s': %v", tools.ErrInvalidInput, step.Name, err)
+			}
+			run = "sh -c " + strconv.Quote(script)
+		}
+		fmt.Fprintf(w, "%d\t%s\t%s\t%s\t%t\t%s\n", i+1, step.Name, run, step.When.String(), step.When.match(project), pipelineWith(step.With))
+	}
+	return w.Flush()
+}
+
+// String the conditions of the step, '-' for the step without conditions
+func (c pipelineCondition) String() string {
+	var items []string
+	if len(c.Branch) > 0 {
+		items = append(items, "branch="+c.Branch)
+	}
+	if c.Patch != nil {
+		items = append(items, fmt.Sprintf("patch=%t", *c.Patch))
+	}
+	if c.Tag != nil {
+		items = append(items, fmt.Sprintf("tag=%t", *c.Tag))
+	}
+	if len(items) == 0 {
+		return "-"
+	}
+	return strings.Join(items, ",")
+}
+
+// pipelineWith the sorted values of the step, '-' for the step without values
+func pipelineWith(with map[string]interface{}) string {
+	if len(with) == 0 {
+		return "-"
+	}
+	keys := make([]string, 0, len(with))
+	for k := range with {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	items := make([]string, 0, len(keys))
+	for _, k := range keys {
+		value := fmt.Sprint(with[k])
+		if log.IsSecretName(k) {
+			value = log.Redacted
+		}
+		items = append(items, k+"="+log.Redact(value))
+	}
+	return strings.Join(items, ",")
+}
+
+func printPipelineSummary(name string, results []pipelineResult) {
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintf(w, "PIPELINE %s\n", name)
+	fmt.Fprintln(w, "STEP\tSTATUS\tDURATION")
+	for _, r := range results {
+		duration := "-"
+		if r.Duration > 0 {
+			duration = r.Duration.String()
+		}
+		fmt.Fprintf(w, "%s\t%s\t%s\n", r.Name, r.Status, duration)
+	}
+	_ = w.Flush()
+}
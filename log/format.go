@@ -0,0 +1,145 @@
+package log
+
+import (
+	"bytes"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/rs/zerolog"
+)
+
+// CorrelationField name of the correlation ID field
+const CorrelationField = "correlation_id"
+
+// logFile the log file of the --log-file option
+var logFile *os.File
+
+// Configure the log format console, json or logfmt and the optional log file. The log file has the same format,
+// the console format is written without colors. The secrets are redacted in all outputs.
+func Configure(format, file string) error {
+	out, err := formatWriter(format, os.Stderr, false)
+	if err != nil {
+		return err
+	}
+	if len(file) > 0 {
+		f, err := os.OpenFile(file, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0644)
+		if err != nil {
+			return fmt.Errorf("error open log file %s: %w", file, err)
+		}
+		fw, err := formatWriter(format, f, true)
+		if err != nil {
+			_ = f.Close()
+			return err
+		}
+		Close()
+		logFile = f
+		out = zerolog.MultiLevelWriter(out, fw)
+	}
+	zerolog.TimeFieldFormat = time.RFC3339
+	logger = logger.Output(out)
+	return nil
+}
+
+// Close the log file
+func Close() {
+	if logFile != nil {
+		_ = logFile.Close()
+		logFile = nil
+	}
+}
+
+// SetCorrelationID add the correlation ID field to all log events
+func SetCorrelationID(id string) {
+	logger = logger.With().Str(CorrelationField, id).Logger()
+}
+
+// NewCorrelationID create random correlation ID
+func NewCorrelationID() string {
+	b := make([]byte, 8)
+	if _, err := rand.Read(b); err != nil {
+		return fmt.Sprintf("%x", time.Now().UnixNano())
+	}
+	return hex.EncodeToString(b)
+}
+
+func formatWriter(format string, w io.Writer, file bool) (io.Writer, error) {
+	w = redactWriter{out: w}
+	switch format {
+	case "console":
+		return zerolog.ConsoleWriter{Out: w, TimeFormat: time.RFC3339, NoColor: file}, nil
+	case "json":
+		return w, nil
+	case "logfmt":
+		return logfmtWriter{out: w}, nil
+	}
+	return nil, fmt.Errorf("not supported log format %s, one of console | json | logfmt", format)
+}
+
+// logfmtWriter convert the json events to the logfmt lines: time, level and message first, the other fields sorted
+type logfmtWriter struct {
+	out io.Writer
+}
+
+func (w logfmtWriter) Write(p []byte) (int, error) {
+	event := map[string]interface{}{}
+	d := json.NewDecoder(bytes.NewReader(p))
+	d.UseNumber()
+	if err := d.Decode(&event); err != nil {
+		return 0, fmt.Errorf("error decode log event: %w", err)
+	}
+
+	var sb strings.Builder
+	write := func(k string) {
+		v, exists := event[k]
+		if !exists {
+			return
+		}
+		if sb.Len() > 0 {
+			sb.WriteString(" ")
+		}
+		sb.WriteString(k + "=" + logfmtValue(v))
+		delete(event, k)
+	}
+	write(zerolog.TimestampFieldName)
+	write(zerolog.LevelFieldName)
+	write(zerolog.MessageFieldName)
+	keys := make([]string, 0, len(event))
+	for k := range event {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	for _, k := range keys {
+		write(k)
+	}
+	sb.WriteString("\n")
+	if _, err := io.WriteString(w.out, sb.String()); err != nil {
+		return 0, err
+	}
+	return len(p), nil
+}
+
+func logfmtValue(v interface{}) string {
+	var s string
+	switch t := v.(type) {
+	case string:
+		s = t
+	case json.Number:
+		return t.String()
+	case bool, nil:
+		return fmt.Sprint(t)
+	default:
+		data, _ := json.Marshal(t)
+		s = string(data)
+	}
+	if s == "" || strings.ContainsAny(s, " =\"\t\n\r\\") {
+		return fmt.Sprintf("%q", s)
+	}
+	return s
+}
@@ -2,6 +2,7 @@ package log
 
 import (
 	"os"
+	"time"
 
 	"github.com/rs/zerolog"
 	zlog "github.com/rs/zerolog/log"
@@ -26,18 +27,19 @@ func E(err error) Fields {
 	return Fields{}.E(err)
 }
 
-var logger = zlog.Output(zerolog.ConsoleWriter{Out: os.Stderr, TimeFormat: zerolog.TimeFormatUnix}).With().Logger().Level(zerolog.InfoLevel)
+var logger = zlog.Output(zerolog.ConsoleWriter{Out: redactWriter{out: os.Stderr}, TimeFormat: time.RFC3339}).With().Logger().Level(zerolog.InfoLevel)
 
 func DefaultLevel() string {
 	return logger.GetLevel().String()
 }
 
-func SetLevel(level string) {
+func SetLevel(level string) error {
 	lvl, err := zerolog.ParseLevel(level)
 	if err != nil {
-		Fatal("error parse log level", E(err))
+		return err
 	}
 	logger = logger.Level(lvl)
+	return nil
 }
 
 func IsDebugLevel() bool {
@@ -56,6 +58,10 @@ func Info(msg string, fields ...map[string]interface{}) {
 	sendEvent(logger.Info(), msg, fields...)
 }
 
+func Warn(msg string, fields ...map[string]interface{}) {
+	sendEvent(logger.Warn(), msg, fields...)
+}
+
 func Error(msg string, fields ...map[string]interface{}) {
 	sendEvent(logger.Error(), msg, fields...)
 }
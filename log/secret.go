@@ -0,0 +1,111 @@
+package log
+
+import (
+	"encoding/json"
+	"io"
+	"os"
+	"regexp"
+	"sort"
+	"strings"
+	"sync"
+)
+
+// Redacted replacement of the secret values
+const Redacted = "*****"
+
+// secretMinLength minimum length of the registered secret, the short values are common words
+const secretMinLength = 4
+
+// secretNameRegex names of the flags and environment variables with the secret values
+var secretNameRegex = regexp.MustCompile(`(?i)(password|passwd|token|secret|credential|api[_-]?key|private[_-]?key|auth[_-]?token|_auth$)`)
+
+// secretPatterns patterns of the secrets in the text with the replacement
+var secretPatterns = []struct {
+	regex       *regexp.Regexp
+	replacement string
+}{
+	// user:pass@ in the URL
+	{regex: regexp.MustCompile(`(://[^:/@\s",}]+:)[^@\s",}]+@`), replacement: "${1}" + Redacted + "@"},
+	// authorization header, the value ends at the JSON or logfmt field boundary
+	{regex: regexp.MustCompile(`(?i)((?:authorization|private-token):\s*(?:bearer\s+|token\s+|basic\s+)?)[^\s",}]+`), replacement: "${1}" + Redacted},
+	// GitHub tokens
+	{regex: regexp.MustCompile(`\b(?:gh[pousr]_[A-Za-z0-9]{36,}|github_pat_[A-Za-z0-9_]{22,})`), replacement: Redacted},
+	// GitLab tokens
+	{regex: regexp.MustCompile(`\bgl(?:pat|dt|rt|cbt|ptt)-[A-Za-z0-9_-]{20,}`), replacement: Redacted},
+}
+
+var secrets = struct {
+	sync.RWMutex
+	values []string
+}{}
+
+// AddSecret register the secret values, the values are redacted in the log output
+func AddSecret(values ...string) {
+	secrets.Lock()
+	defer secrets.Unlock()
+	for _, v := range values {
+		if len(v) < secretMinLength {
+			continue
+		}
+		add := []string{v}
+		// escaped value of the json log output
+		if data, err := json.Marshal(v); err == nil {
+			if escaped := string(data[1 : len(data)-1]); escaped != v {
+				add = append(add, escaped)
+			}
+		}
+		for _, a := range add {
+			exists := false
+			for _, s := range secrets.values {
+				exists = exists || s == a
+			}
+			if !exists {
+				secrets.values = append(secrets.values, a)
+			}
+		}
+	}
+	// replace the longest secrets first
+	sort.SliceStable(secrets.values, func(i, j int) bool {
+		return len(secrets.values[i]) > len(secrets.values[j])
+	})
+}
+
+// IsSecretName the flag or environment variable name is the name of the secret value
+func IsSecretName(name string) bool {
+	return secretNameRegex.MatchString(name)
+}
+
+// AddSecretEnv register the values of the environment variables with the secret names
+func AddSecretEnv() {
+	for _, env := range os.Environ() {
+		items := strings.SplitN(env, "=", 2)
+		if len(items) == 2 && IsSecretName(items[0]) {
+			AddSecret(items[1])
+		}
+	}
+}
+
+// Redact replace the registered secrets and the secret patterns in the text
+func Redact(text string) string {
+	secrets.RLock()
+	for _, s := range secrets.values {
+		text = strings.ReplaceAll(text, s, Redacted)
+	}
+	secrets.RUnlock()
+	for _, p := range secretPatterns {
+		text = p.regex.ReplaceAllString(text, p.replacement)
+	}
+	return text
+}
+
+// redactWriter redact the secrets of the log output
+type redactWriter struct {
+	out io.Writer
+}
+
+func (w redactWriter) Write(p []byte) (int, error) {
+	if _, err := io.WriteString(w.out, Redact(string(p))); err != nil {
+		return 0, err
+	}
+	return len(p), nil
+}
@@ -0,0 +1,65 @@
+package samo
+
+import "strings"
+
+// changelogSections sections of the changelog in the order of the output
+var changelogSections = []struct {
+	title      string
+	categories []string
+}{
+	{title: "Features", categories: []string{"feat"}},
+	{title: "Bug Fixes", categories: []string{"fix"}},
+	{title: "Performance", categories: []string{"perf"}},
+	{title: "Other Changes", categories: nil},
+}
+
+// Changelog create the markdown changelog from the commit messages grouped by the conventional commit type
+func Changelog(commits []string) string {
+	var breaking []string
+	groups := map[string][]string{}
+
+	for _, commit := range commits {
+		if len(strings.TrimSpace(commit)) == 0 {
+			continue
+		}
+		item := ParseCommit(commit)
+		line := CommitHeader(commit)
+		if len(item.Category) > 0 && len(item.Description) > 0 {
+			line = item.Description
+			if len(item.Scope) > 0 {
+				line = "**" + item.Scope + ":** " + line
+			}
+		}
+		if item.Major {
+			breaking = append(breaking, line)
+		}
+		section := changelogSections[len(changelogSections)-1].title
+		for _, s := range changelogSections {
+			for _, c := range s.categories {
+				if c == item.Category {
+					section = s.title
+				}
+			}
+		}
+		groups[section] = append(groups[section], line)
+	}
+
+	var sb strings.Builder
+	writeSection := func(title string, lines []string) {
+		if len(lines) == 0 {
+			return
+		}
+		if sb.Len() > 0 {
+			sb.WriteString("\n")
+		}
+		sb.WriteString("### " + title + "\n\n")
+		for _, l := range lines {
+			sb.WriteString("* " + l + "\n")
+		}
+	}
+	writeSection("Breaking Changes", breaking)
+	for _, s := range changelogSections {
+		writeSection(s.title, groups[s.title])
+	}
+	return sb.String()
+}
@@ -0,0 +1,82 @@
+package samo
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+
+	"github.com/Masterminds/semver/v3"
+	"github.com/lorislab/samo/tools"
+	cc "gitlab.com/digitalxero/go-conventional-commit"
+)
+
+// ReleaseAsTrailer trailer of the commit which forces the release version
+const ReleaseAsTrailer = "Release-As"
+
+// trailerRegex git trailer 'Key: value' of the last paragraph, BREAKING CHANGE is the only key with the space
+var trailerRegex = regexp.MustCompile(`^(BREAKING CHANGE|[\w-]+): (.*)$`)
+
+// CommitHeader first line of the commit message
+func CommitHeader(message string) string {
+	return strings.TrimSpace(strings.SplitN(strings.TrimSpace(message), "\n", 2)[0])
+}
+
+// CommitTrailers trailers of the last paragraph of the commit message. The key is the trailer key as written.
+func CommitTrailers(message string) map[string][]string {
+	paragraphs := strings.Split(strings.TrimSpace(strings.ReplaceAll(message, "\r\n", "\n")), "\n\n")
+	// the header is not the trailer paragraph
+	if len(paragraphs) < 2 {
+		return nil
+	}
+	result := map[string][]string{}
+	last := ""
+	for _, line := range strings.Split(paragraphs[len(paragraphs)-1], "\n") {
+		match := trailerRegex.FindStringSubmatch(line)
+		switch {
+		case match != nil:
+			last = match[1]
+			result[last] = append(result[last], strings.TrimSpace(match[2]))
+		case len(last) > 0 && (strings.HasPrefix(line, " ") || strings.HasPrefix(line, "\t")):
+			// folded value of the trailer
+			values := result[last]
+			values[len(values)-1] += " " + strings.TrimSpace(line)
+		default:
+			// not a trailer paragraph
+			return nil
+		}
+	}
+	return result
+}
+
+// ParseCommit parse the conventional commit. The breaking change is the ! of the header or the
+// BREAKING CHANGE / BREAKING-CHANGE trailer of the footer, the text of the body is not a breaking change.
+func ParseCommit(message string) *cc.ConventionalCommit {
+	item := cc.ParseConventionalCommit(strings.TrimSpace(message))
+	// the header without the body decides the type of the commit
+	header := cc.ParseConventionalCommit(CommitHeader(message))
+	trailers := CommitTrailers(message)
+	_, breaking := trailers["BREAKING CHANGE"]
+	_, breakingDash := trailers["BREAKING-CHANGE"]
+	item.Major = header.Major || breaking || breakingDash
+	item.Minor = !item.Major && header.Minor
+	item.Patch = !item.Major && header.Patch
+	return item
+}
+
+// ReleaseAs the version of the newest Release-As trailer, the commits are in the git log order (newest first).
+// Returns nil if no commit has the trailer.
+func ReleaseAs(commits []string) (*semver.Version, string, error) {
+	for _, commit := range commits {
+		values := CommitTrailers(commit)[ReleaseAsTrailer]
+		if len(values) == 0 {
+			continue
+		}
+		value := values[len(values)-1]
+		ver, err := semver.StrictNewVersion(strings.TrimPrefix(value, "v"))
+		if err != nil {
+			return nil, "", fmt.Errorf("%w: invalid %s trailer '%s' of the commit '%s'", tools.ErrInvalidVersion, ReleaseAsTrailer, value, CommitHeader(commit))
+		}
+		return ver, commit, nil
+	}
+	return nil, "", nil
+}
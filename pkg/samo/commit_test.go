@@ -0,0 +1,28 @@
+package samo
+
+import "testing"
+
+func TestParseCommit(t *testing.T) {
+	tests := []struct {
+		name         string
+		message      string
+		major, minor bool
+	}{
+		{name: "feature", message: "feat: a", minor: true},
+		{name: "fix", message: "fix: a"},
+		{name: "header breaking", message: "fix!: a", major: true},
+		{name: "footer breaking", message: "fix: a\n\nbody\n\nBREAKING CHANGE: removed api", major: true},
+		{name: "footer breaking dash", message: "feat: a\n\nBREAKING-CHANGE: removed api\nRefs: #1", major: true},
+		{name: "body breaking", message: "fix: a\n\nbody mentions BREAKING CHANGE: not a footer\nmore text"},
+		{name: "body breaking feature", message: "feat: a\n\nBREAKING CHANGE: in the body\nmore text\n\nRefs: #1", minor: true},
+		{name: "no conventional header", message: "update\n\nthe BREAKING CHANGE of the text"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			item := ParseCommit(tt.message)
+			if item.Major != tt.major || item.Minor != tt.minor {
+				t.Errorf("major %v minor %v, expected major %v minor %v", item.Major, item.Minor, tt.major, tt.minor)
+			}
+		})
+	}
+}
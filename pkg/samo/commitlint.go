@@ -0,0 +1,109 @@
+package samo
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+// DefaultCommitTypes default allowed types of the conventional commits
+var DefaultCommitTypes = []string{"build", "chore", "ci", "docs", "feat", "fix", "perf", "refactor", "revert", "style", "test"}
+
+// CommitLintRules rules of the commit message lint
+type CommitLintRules struct {
+	// Types allowed commit types. Default DefaultCommitTypes.
+	Types []string
+	// Scopes allowed commit scopes. Empty list allows all scopes.
+	Scopes []string
+	// SubjectMaxLength maximum length of the header. Zero disables the check.
+	SubjectMaxLength int
+}
+
+// commitHeaderRegex conventional commit header type(scope)!: description
+var commitHeaderRegex = regexp.MustCompile(`^(?P<type>[a-zA-Z]+)(\((?P<scope>[^()\r\n]*)\))?(?P<breaking>!)?: (?P<description>.*)$`)
+
+// breakingFooterRegex valid breaking change footer
+var breakingFooterRegex = regexp.MustCompile(`^BREAKING[ -]CHANGE: \S`)
+
+// breakingTrailerRegex footer line written as the breaking change trailer, the prose starting with 'Breaking changes' is not a trailer
+var breakingTrailerRegex = regexp.MustCompile(`(?i)^breaking[ -]change\s*[:#-]`)
+
+// LintCommit validate the commit message against the conventional commit grammar, returns the list of the issues
+func LintCommit(message string, rules CommitLintRules) []string {
+	types := rules.Types
+	if len(types) == 0 {
+		types = DefaultCommitTypes
+	}
+	lines := strings.Split(strings.TrimSpace(strings.ReplaceAll(message, "\r\n", "\n")), "\n")
+	header := lines[0]
+
+	var issues []string
+	match := commitHeaderRegex.FindStringSubmatch(header)
+	index := commitHeaderRegex.FindStringSubmatchIndex(header)
+	if match == nil {
+		issues = append(issues, "header must match 'type(scope)!: description'")
+	} else {
+		typ := match[commitHeaderRegex.SubexpIndex("type")]
+		scope := match[commitHeaderRegex.SubexpIndex("scope")]
+		description := match[commitHeaderRegex.SubexpIndex("description")]
+		if !containsString(types, typ) {
+			issues = append(issues, fmt.Sprintf("type '%s' is not allowed, allowed types: %s", typ, strings.Join(types, ",")))
+		}
+		// the scope group took part in the match, the description could contain the parenthesis
+		scopeMatched := index[2*commitHeaderRegex.SubexpIndex("scope")] != -1
+		if scopeMatched && len(strings.TrimSpace(scope)) == 0 {
+			issues = append(issues, "scope must not be empty")
+		} else if len(scope) > 0 && len(rules.Scopes) > 0 && !containsString(rules.Scopes, scope) {
+			issues = append(issues, fmt.Sprintf("scope '%s' is not allowed, allowed scopes: %s", scope, strings.Join(rules.Scopes, ",")))
+		}
+		if len(strings.TrimSpace(description)) == 0 {
+			issues = append(issues, "description must not be empty")
+		}
+	}
+	if rules.SubjectMaxLength > 0 && len([]rune(header)) > rules.SubjectMaxLength {
+		issues = append(issues, fmt.Sprintf("header is longer than %d characters (%d)", rules.SubjectMaxLength, len([]rune(header))))
+	}
+	if len(lines) > 1 && len(strings.TrimSpace(lines[1])) > 0 {
+		issues = append(issues, "body must be separated from the header by a blank line")
+	}
+	for _, line := range commitFooter(message) {
+		if breakingTrailerRegex.MatchString(line) && !breakingFooterRegex.MatchString(line) {
+			issues = append(issues, fmt.Sprintf("invalid breaking change footer '%s', expected 'BREAKING CHANGE: description'", line))
+		}
+	}
+	return issues
+}
+
+// commitFooter lines of the last paragraph of the commit message, the header is not the footer
+func commitFooter(message string) []string {
+	paragraphs := strings.Split(strings.TrimSpace(strings.ReplaceAll(message, "\r\n", "\n")), "\n\n")
+	if len(paragraphs) < 2 {
+		return nil
+	}
+	return strings.Split(paragraphs[len(paragraphs)-1], "\n")
+}
+
+// CommitMessageFromFile clean the commit message of the commit-msg hook, removes the comments and the diff of the verbose commit
+func CommitMessageFromFile(content string) string {
+	var lines []string
+	for _, line := range strings.Split(content, "\n") {
+		// git commit --verbose scissors line
+		if strings.HasPrefix(line, "# ------------------------ >8 ------------------------") {
+			break
+		}
+		if strings.HasPrefix(line, "#") {
+			continue
+		}
+		lines = append(lines, line)
+	}
+	return strings.TrimSpace(strings.Join(lines, "\n"))
+}
+
+func containsString(items []string, value string) bool {
+	for _, item := range items {
+		if item == value {
+			return true
+		}
+	}
+	return false
+}
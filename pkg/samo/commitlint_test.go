@@ -0,0 +1,36 @@
+package samo
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestLintCommit(t *testing.T) {
+	tests := []struct {
+		name    string
+		message string
+		issue   string
+	}{
+		{name: "valid", message: "feat(api): add endpoint"},
+		{name: "parenthesis in description", message: "fix: handle nil (empty) values"},
+		{name: "empty scope", message: "fix(): handle nil", issue: "scope must not be empty"},
+		{name: "blank scope", message: "fix( ): handle nil", issue: "scope must not be empty"},
+		{name: "prose breaking changes", message: "feat: a\n\nBreaking changes are documented in the guide.\n\nRefs: #1"},
+		{name: "invalid breaking footer", message: "feat: a\n\nbreaking change: removed", issue: "invalid breaking change footer"},
+		{name: "valid breaking footer", message: "feat: a\n\nBREAKING CHANGE: removed"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			issues := LintCommit(tt.message, CommitLintRules{})
+			if len(tt.issue) == 0 {
+				if len(issues) > 0 {
+					t.Errorf("unexpected issues %v", issues)
+				}
+				return
+			}
+			if len(issues) != 1 || !strings.Contains(issues[0], tt.issue) {
+				t.Errorf("issues %v, expected '%s'", issues, tt.issue)
+			}
+		})
+	}
+}
@@ -0,0 +1,13 @@
+// Package samo is the library of the samo release tool.
+//
+// It computes the semantic version of a git project and builds the docker
+// and helm operations of the project. The samo command line is a thin
+// wrapper over this package.
+//
+//	git := samo.NewCommandGit()
+//	project, err := samo.New(ctx, samo.Options{FirstVersion: "0.0.0"}, git)
+//	if err != nil {
+//		return err
+//	}
+//	fmt.Println(project.Version())
+package samo
@@ -0,0 +1,246 @@
+package samo
+
+import (
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/lorislab/samo/tools"
+)
+
+// A tag name must be valid ASCII and may contain lowercase and uppercase letters, digits, underscores,
+// periods and hyphens. A tag name may not start with a period or a hyphen and may contain a maximum
+// of 128 characters.
+// [a-z][A-Z][0-9]_.-
+var dockerTagRegex = regexp.MustCompile(`[^a-zA-Z0-9_.-]+`)
+
+// DockerImage create the docker image name. Default repository is the project name.
+func DockerImage(project *Project, registry, group, repository string) string {
+	dockerImage := repository
+	if len(dockerImage) == 0 {
+		dockerImage = project.Name()
+	}
+	if len(group) > 0 {
+		if !strings.HasSuffix(group, `/`) {
+			group = group + "/"
+		}
+		dockerImage = group + dockerImage
+	}
+	if len(registry) > 0 {
+		dockerImage = registry + "/" + dockerImage
+	}
+	return dockerImage
+}
+
+// DockerTags create the docker image tags from the comma separated tag template.
+// For empty image only the tags are returned.
+func DockerTags(project *Project, dockerImage, template string) ([]string, error) {
+	tagTemplate, err := tools.Template(project, template)
+	if err != nil {
+		return nil, err
+	}
+	items := strings.Split(tagTemplate, ",")
+
+	var tags []string
+	for _, tag := range items {
+		var t = DockerReplaceTag(tag)
+		if len(dockerImage) > 0 {
+			tags = append(tags, DockerImageTag(dockerImage, t))
+		} else {
+			tags = append(tags, t)
+		}
+	}
+	return tags, nil
+}
+
+// DockerReplaceTag replace all not supported characters of the tag
+func DockerReplaceTag(tag string) string {
+	return dockerTagRegex.ReplaceAllString(tag, "_")
+}
+
+// DockerImageTag create image with the tag
+func DockerImageTag(dockerImage, tag string) string {
+	return dockerImage + ":" + tag
+}
+
+// DockerImageTagTemplate create image with the tag template
+func DockerImageTagTemplate(project *Project, dockerImage, template string) (string, error) {
+	tagTemplate, err := tools.Template(project, template)
+	if err != nil {
+		return "", err
+	}
+	return dockerImage + ":" + tagTemplate, nil
+}
+
+// DockerLabelOptions options of the docker labels
+type DockerLabelOptions struct {
+	// SkipSamo skip samo.project.* labels
+	SkipSamo bool
+	// SkipOpenContainers skip org.opencontainers.image.* labels
+	SkipOpenContainers bool
+	// Template custom labels template list. Example: my-label={{ .Branch }},my-const=123
+	Template string
+}
+
+// DockerLabels create docker labels of the project
+func DockerLabels(project *Project, opts DockerLabelOptions) (map[string]string, error) {
+
+	result := map[string]string{}
+
+	created := time.Now().Format(time.RFC3339)
+
+	// add labels
+	if !opts.SkipSamo {
+		result["samo.project.revision"] = project.Hash()
+		result["samo.project.version"] = project.Version()
+		result["samo.project.created"] = created
+	}
+
+	// add open-containers labels
+	if !opts.SkipOpenContainers {
+		result["org.opencontainers.image.created"] = created
+		result["org.opencontainers.image.title"] = project.Name()
+		result["org.opencontainers.image.description"] = project.Description()
+		result["org.opencontainers.image.revision"] = project.Hash()
+		result["org.opencontainers.image.version"] = project.Version()
+		result["org.opencontainers.image.source"] = project.Url()
+	}
+
+	// add custom labels
+	if len(opts.Template) > 0 {
+		labelTemplate, err := tools.Template(project, opts.Template)
+		if err != nil {
+			return nil, err
+		}
+		labels := strings.Split(labelTemplate, ",")
+		for _, label := range labels {
+			kv := strings.Split(label, "=")
+			if len(kv) > 1 {
+				result[kv[0]] = kv[1]
+			}
+		}
+	}
+
+	return result, nil
+}
+
+// DockerBuild docker build operation
+type DockerBuild struct {
+	File       string
+	Context    string
+	Platform   string
+	Provenance string
+	Tags       []string
+	Labels     map[string]string
+	BuildX     bool
+	SkipPull   bool
+	SkipRemove bool
+	// Annotations add the labels as annotations with the prefix, only for buildx
+	Annotations      bool
+	AnnotationPrefix string
+	// Push the images, buildx push the images in the build command
+	Push bool
+}
+
+// Commands create the docker commands of the build
+func (b DockerBuild) Commands() []Command {
+	var command []string
+	if b.BuildX {
+		command = append(command, "buildx")
+	}
+	command = append(command, "build")
+	if !b.SkipPull {
+		command = append(command, "--pull")
+	}
+
+	// Removing intermediate container
+	if !b.SkipRemove {
+		command = append(command, "--rm")
+	}
+	if len(b.Provenance) > 0 {
+		command = append(command, "--provenance", b.Provenance)
+	}
+	if len(b.Platform) > 0 {
+		command = append(command, "--platform", b.Platform)
+	}
+
+	keys := make([]string, 0, len(b.Labels))
+	for key := range b.Labels {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	for _, key := range keys {
+		command = append(command, "--label", key+"="+b.Labels[key])
+	}
+
+	// create annotations
+	if b.BuildX && b.Annotations {
+		for _, key := range keys {
+			command = append(command, "--annotation", b.AnnotationPrefix+key+"="+b.Labels[key])
+		}
+	}
+
+	// add tags
+	for _, tag := range b.Tags {
+		command = append(command, "-t", tag)
+	}
+
+	// add dockerfile and dockerfile profile
+	command = append(command, "-f", b.File)
+
+	// push images for buildx
+	if b.BuildX && b.Push {
+		command = append(command, "--push")
+	}
+
+	// set docker context
+	command = append(command, b.Context)
+
+	result := []Command{Cmd("docker", command...)}
+
+	// for none buildx we need to push it manually
+	if !b.BuildX && b.Push {
+		result = append(result, DockerPush(b.Tags)...)
+	}
+	return result
+}
+
+// DockerPush create the docker push commands of the tags
+func DockerPush(tags []string) []Command {
+	var result []Command
+	for _, tag := range tags {
+		result = append(result, RetryCmd("docker", "push", tag))
+	}
+	return result
+}
+
+// DockerRetag create the docker commands to pull the image and tag it with the tags
+func DockerRetag(image string, tags []string) []Command {
+	result := []Command{RetryCmd("docker", "pull", image)}
+	for _, tag := range tags {
+		result = append(result, Cmd("docker", "tag", image, tag))
+	}
+	return result
+}
+
+// DockerImageTools create the buildx imagetools command to create the tags from the source image
+func DockerImageTools(image string, tags []string, dryRun bool) Command {
+
+	// buildx imagetools create
+	command := []string{"buildx", "imagetools", "create"}
+
+	// dry run
+	if dryRun {
+		command = append(command, "--dry-run")
+	}
+
+	// add new tags
+	for _, tag := range tags {
+		command = append(command, "-t", tag)
+	}
+
+	// add remote repository image
+	command = append(command, image)
+	return RetryCmd("docker", command...)
+}
@@ -0,0 +1,27 @@
+package samo_test
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/lorislab/samo/pkg/samo"
+)
+
+func ExampleNewFakeRunner() {
+	fake := samo.NewFakeRunner().
+		On("git config remote.origin.url", "https://github.com/lorislab/samo.git", nil).
+		On("git describe", "1.0.0-2-gabc", nil).
+		On("git rev-parse --abbrev-ref HEAD", "main", nil)
+	ctx := samo.WithRunner(context.Background(), fake)
+
+	project, err := samo.New(ctx, samo.Options{FirstVersion: "0.0.0", VersionTemplate: "{{ .Version }}-rc.{{ .Count }}"}, samo.NewCommandGit())
+	if err != nil {
+		panic(err)
+	}
+	build := samo.DockerBuild{File: "Dockerfile", Context: ".", Tags: []string{"samo:" + project.Version()}}
+	if err := samo.Run(ctx, build.Commands()...); err != nil {
+		panic(err)
+	}
+	fmt.Println(fake.Lines())
+	// Output: [git config remote.origin.url git describe --long --abbrev=100 git rev-parse --abbrev-ref HEAD docker build --pull --rm -t samo:1.1.0-rc.2 -f Dockerfile .]
+}
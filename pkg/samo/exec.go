@@ -0,0 +1,60 @@
+package samo
+
+import (
+	"context"
+
+	"github.com/lorislab/samo/tools"
+)
+
+// Command external command of the operation
+type Command = tools.Command
+
+// Runner execute the external commands of the operations
+type Runner = tools.Runner
+
+// ExecOptions timeout and retry policy of the external commands
+type ExecOptions = tools.ExecOptions
+
+// FakeRunner runner which records the commands and returns scripted outputs
+type FakeRunner = tools.FakeRunner
+
+// NewFakeRunner create fake runner for the tests
+func NewFakeRunner() *FakeRunner {
+	return tools.NewFakeRunner()
+}
+
+// WithRunner context with the runner of the operations and git commands
+func WithRunner(ctx context.Context, r Runner) context.Context {
+	return tools.WithRunner(ctx, r)
+}
+
+// WithExecOptions context with the timeout and the retry policy of the operations and git commands
+func WithExecOptions(ctx context.Context, opts ExecOptions) context.Context {
+	return tools.WithExecOptions(ctx, opts)
+}
+
+// Cmd create command
+func Cmd(name string, args ...string) Command {
+	return tools.NewCommand(name, args...)
+}
+
+// RetryCmd create command which is retried on failure with the retry policy
+func RetryCmd(name string, args ...string) Command {
+	c := tools.NewCommand(name, args...)
+	c.Retry = true
+	return c
+}
+
+// Run execute the commands in sequence, stops on the first error or cancelled context.
+// The commands are executed with the timeout and the retry policy of the exec options.
+func Run(ctx context.Context, cmds ...Command) error {
+	for _, c := range cmds {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		if err := tools.Run(ctx, c); err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,97 @@
+package samo
+
+import (
+	"fmt"
+	"io"
+	"strings"
+)
+
+// Explanation decision trace of the project version calculation
+type Explanation struct {
+	// Tag last tag of the git describe
+	Tag   string `json:"tag"`
+	Count string `json:"count"`
+	Hash  string `json:"hash"`
+	// FirstVersion version of the repository without tag
+	FirstVersion string `json:"firstVersion,omitempty"`
+	Branch       string `json:"branch"`
+	// PatchBranch patch branch name of the tag
+	PatchBranch string `json:"patchBranch,omitempty"`
+	// ReleaseBranch release version of the release branch
+	ReleaseBranch    string `json:"releaseBranch,omitempty"`
+	PatchBuild       bool   `json:"patchBuild"`
+	PatchBuildReason string `json:"patchBuildReason,omitempty"`
+	// Strategy decision of the version strategy
+	Strategy *StrategyExplanation `json:"strategy,omitempty"`
+	// NextVersion version after the release branches
+	NextVersion     string `json:"nextVersion"`
+	VersionTemplate string `json:"versionTemplate"`
+	Version         string `json:"version"`
+	Release         string `json:"release"`
+	// ReleaseCandidate versions of the release candidate of the tag at HEAD
+	ReleaseCandidate *ReleaseCandidateExplanation `json:"releaseCandidate,omitempty"`
+	// Steps of the calculation in the readable form
+	Steps []string `json:"steps"`
+}
+
+// StrategyExplanation decision of the version strategy
+type StrategyExplanation struct {
+	Name string `json:"name"`
+	// Bump increased part of the version major, minor or patch
+	Bump   string `json:"bump"`
+	Reason string `json:"reason"`
+	// Commits considered commit messages
+	Commits []string `json:"commits,omitempty"`
+	// Commit the commit message which decided the bump
+	Commit string `json:"commit,omitempty"`
+}
+
+// ReleaseCandidateExplanation the release candidate of the tag at HEAD
+type ReleaseCandidateExplanation struct {
+	// Tag previous tag of the release tag
+	Tag      string               `json:"tag"`
+	Count    string               `json:"count"`
+	Hash     string               `json:"hash"`
+	Release  string               `json:"release"`
+	Version  string               `json:"version"`
+	Strategy *StrategyExplanation `json:"strategy,omitempty"`
+}
+
+// decide set the decision of the strategy
+func (e *StrategyExplanation) decide(name, bump, reason string) {
+	if e == nil {
+		return
+	}
+	e.Name = name
+	e.Bump = bump
+	e.Reason = reason
+}
+
+// defaultName name of the custom strategy without the explanation
+func (e *StrategyExplanation) defaultName(strategy VersionStrategy) {
+	if len(e.Name) == 0 {
+		e.Name = fmt.Sprintf("%T", strategy)
+	}
+}
+
+func (e *Explanation) step(format string, args ...interface{}) {
+	e.Steps = append(e.Steps, fmt.Sprintf(format, args...))
+}
+
+// WriteText write the decision trace in the readable form
+func (e *Explanation) WriteText(w io.Writer) error {
+	var sb strings.Builder
+	for i, s := range e.Steps {
+		sb.WriteString(fmt.Sprintf("%2d. %s\n", i+1, s))
+	}
+	if e.Strategy != nil && len(e.Strategy.Commits) > 0 {
+		sb.WriteString("Commits:\n")
+		for _, c := range e.Strategy.Commits {
+			sb.WriteString("  " + c + "\n")
+		}
+	}
+	sb.WriteString("Version: " + e.Version + "\n")
+	sb.WriteString("Release: " + e.Release + "\n")
+	_, err := io.WriteString(w, sb.String())
+	return err
+}
@@ -0,0 +1,334 @@
+package samo
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"mime/multipart"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"github.com/lorislab/samo/log"
+	"github.com/lorislab/samo/tools"
+)
+
+// ForgeRelease release object of the forge
+type ForgeRelease struct {
+	Tag        string
+	Name       string
+	Body       string
+	Draft      bool
+	Prerelease bool
+	// Assets files uploaded to the release
+	Assets []string
+}
+
+// Forge git hosting service with the release API
+type Forge interface {
+	// CreateRelease create the release of the existing tag, returns the URL of the release
+	CreateRelease(ctx context.Context, release ForgeRelease) (string, error)
+}
+
+// ForgeOptions options of the forge client
+type ForgeOptions struct {
+	// Kind of the forge: github, gitlab or gitea
+	Kind string
+	// URL of the REST API. Default public API of github and gitlab.
+	URL string
+	// Repository path owner/name
+	Repository string
+	// Token of the API
+	Token string
+	// Client HTTP client. Default http.DefaultClient.
+	Client *http.Client
+}
+
+// forgeTokenEnv environment variables of the forge token
+var forgeTokenEnv = map[string][]string{
+	"github": {"SAMO_FORGE_TOKEN", "GITHUB_TOKEN"},
+	"gitlab": {"SAMO_FORGE_TOKEN", "GITLAB_TOKEN"},
+	"gitea":  {"SAMO_FORGE_TOKEN", "GITEA_TOKEN"},
+}
+
+// ForgeToken read the token of the forge from the environment variables
+func ForgeToken(kind string) string {
+	for _, env := range forgeTokenEnv[kind] {
+		if v := os.Getenv(env); len(v) > 0 {
+			return v
+		}
+	}
+	return ""
+}
+
+// ForgeRepository create the repository path owner/name from the git source
+func ForgeRepository(source string) string {
+	repo := strings.TrimSuffix(source, ".git")
+	if strings.HasPrefix(repo, "git@") {
+		if i := strings.Index(repo, ":"); i >= 0 {
+			return repo[i+1:]
+		}
+	}
+	if u, err := url.Parse(repo); err == nil && len(u.Host) > 0 {
+		return strings.TrimPrefix(u.Path, "/")
+	}
+	return repo
+}
+
+// NewForge create the forge client
+func NewForge(opts ForgeOptions) (Forge, error) {
+	if len(opts.Token) == 0 {
+		return nil, fmt.Errorf("%w: missing token of the %s API, set %s", tools.ErrInvalidInput, opts.Kind, strings.Join(forgeTokenEnv[opts.Kind], " or "))
+	}
+	if len(opts.Repository) == 0 {
+		return nil, fmt.Errorf("%w: missing repository of the %s release", tools.ErrInvalidInput, opts.Kind)
+	}
+	log.AddSecret(opts.Token)
+	if opts.Client == nil {
+		opts.Client = http.DefaultClient
+	}
+	api := forgeAPI{client: opts.Client, url: strings.TrimSuffix(opts.URL, "/")}
+	switch opts.Kind {
+	case "github":
+		if len(api.url) == 0 {
+			api.url = "https://api.github.com"
+		}
+		api.auth = "Bearer " + opts.Token
+		return &githubForge{api: api, repo: opts.Repository}, nil
+	case "gitlab":
+		if len(api.url) == 0 {
+			api.url = "https://gitlab.com/api/v4"
+		}
+		api.header = "PRIVATE-TOKEN"
+		api.auth = opts.Token
+		return &gitlabForge{api: api, repo: opts.Repository}, nil
+	case "gitea":
+		if len(api.url) == 0 {
+			return nil, fmt.Errorf("%w: missing API URL of the gitea server", tools.ErrInvalidInput)
+		}
+		api.auth = "token " + opts.Token
+		return &giteaForge{api: api, repo: opts.Repository}, nil
+	}
+	return nil, fmt.Errorf("%w: not supported forge %s, one of github | gitlab | gitea", tools.ErrInvalidInput, opts.Kind)
+}
+
+// forgeAPI REST API client
+type forgeAPI struct {
+	client *http.Client
+	url    string
+	// header authorization header name, default Authorization
+	header string
+	auth   string
+}
+
+// ForgeError the forge API returns the error status
+type ForgeError struct {
+	Method string
+	URL    string
+	Status int
+	Body   string
+}
+
+func (e *ForgeError) Error() string {
+	return fmt.Sprintf("forge API %s %s failed with status %d: %s", e.Method, e.URL, e.Status, strings.TrimSpace(e.Body))
+}
+
+// Is the forge error is the command failure
+func (e *ForgeError) Is(target error) bool {
+	return target == tools.ErrCommandFailed
+}
+
+func (a forgeAPI) do(ctx context.Context, method, u, contentType string, body io.Reader, result interface{}) error {
+	req, err := http.NewRequestWithContext(ctx, method, u, body)
+	if err != nil {
+		return err
+	}
+	header := a.header
+	if len(header) == 0 {
+		header = "Authorization"
+	}
+	req.Header.Set(header, a.auth)
+	req.Header.Set("Accept", "application/json")
+	if len(contentType) > 0 {
+		req.Header.Set("Content-Type", contentType)
+	}
+	log.Debug("Forge API", log.F("method", method).F("url", u))
+
+	resp, err := a.client.Do(req)
+	if err != nil {
+		return fmt.Errorf("%w: forge API %s %s: %v", tools.ErrCommandFailed, method, u, err)
+	}
+	defer resp.Body.Close()
+	data, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return fmt.Errorf("%w: read forge API response %s %s: %v", tools.ErrCommandFailed, method, u, err)
+	}
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return &ForgeError{Method: method, URL: u, Status: resp.StatusCode, Body: string(data)}
+	}
+	if result == nil || len(data) == 0 {
+		return nil
+	}
+	if err := json.Unmarshal(data, result); err != nil {
+		return fmt.Errorf("%w: unmarshal forge API response %s %s: %v", tools.ErrCommandFailed, method, u, err)
+	}
+	return nil
+}
+
+func (a forgeAPI) json(ctx context.Context, method, u string, body, result interface{}) error {
+	data, err := json.Marshal(body)
+	if err != nil {
+		return err
+	}
+	return a.do(ctx, method, u, "application/json", bytes.NewReader(data), result)
+}
+
+func (a forgeAPI) file(ctx context.Context, u, filename string, result interface{}) error {
+	data, err := os.ReadFile(filename)
+	if err != nil {
+		return fmt.Errorf("%w: error read release asset %s: %v", tools.ErrPrecondition, filename, err)
+	}
+	return a.do(ctx, http.MethodPost, u, "application/octet-stream", bytes.NewReader(data), result)
+}
+
+func (a forgeAPI) multipart(ctx context.Context, u, field, filename string, result interface{}) error {
+	data, err := os.ReadFile(filename)
+	if err != nil {
+		return fmt.Errorf("%w: error read release asset %s: %v", tools.ErrPrecondition, filename, err)
+	}
+	var buf bytes.Buffer
+	w := multipart.NewWriter(&buf)
+	part, err := w.CreateFormFile(field, filepath.Base(filename))
+	if err != nil {
+		return err
+	}
+	if _, err := part.Write(data); err != nil {
+		return err
+	}
+	if err := w.Close(); err != nil {
+		return err
+	}
+	return a.do(ctx, http.MethodPost, u, w.FormDataContentType(), &buf, result)
+}
+
+// GitHub REST API
+type githubForge struct {
+	api  forgeAPI
+	repo string
+}
+
+func (f *githubForge) CreateRelease(ctx context.Context, release ForgeRelease) (string, error) {
+	body := map[string]interface{}{
+		"tag_name":   release.Tag,
+		"name":       release.Name,
+		"body":       release.Body,
+		"draft":      release.Draft,
+		"prerelease": release.Prerelease,
+	}
+	result := struct {
+		ID        int64  `json:"id"`
+		HTMLURL   string `json:"html_url"`
+		UploadURL string `json:"upload_url"`
+	}{}
+	if err := f.api.json(ctx, http.MethodPost, f.api.url+"/repos/"+f.repo+"/releases", body, &result); err != nil {
+		return "", err
+	}
+
+	// upload_url https://uploads.github.com/repos/o/r/releases/1/assets{?name,label}
+	upload := result.UploadURL
+	if i := strings.Index(upload, "{"); i >= 0 {
+		upload = upload[:i]
+	}
+	if len(upload) == 0 {
+		upload = f.api.url + "/repos/" + f.repo + "/releases/" + strconv.FormatInt(result.ID, 10) + "/assets"
+	}
+	for _, asset := range release.Assets {
+		log.Info("Upload release asset", log.F("asset", asset))
+		if err := f.api.file(ctx, upload+"?name="+url.QueryEscape(filepath.Base(asset)), asset, nil); err != nil {
+			return "", err
+		}
+	}
+	return result.HTMLURL, nil
+}
+
+// GitLab REST API
+type gitlabForge struct {
+	api  forgeAPI
+	repo string
+}
+
+func (f *gitlabForge) CreateRelease(ctx context.Context, release ForgeRelease) (string, error) {
+	project := f.api.url + "/projects/" + url.PathEscape(f.repo)
+	if release.Draft || release.Prerelease {
+		log.Info("GitLab does not support draft or prerelease, the flags are ignored", log.F("tag", release.Tag))
+	}
+
+	// upload assets to the project and add the links to the release
+	web := strings.TrimSuffix(f.api.url, "/api/v4")
+	var links []map[string]string
+	for _, asset := range release.Assets {
+		log.Info("Upload release asset", log.F("asset", asset))
+		result := struct {
+			FullPath string `json:"full_path"`
+		}{}
+		if err := f.api.multipart(ctx, project+"/uploads", "file", asset, &result); err != nil {
+			return "", err
+		}
+		links = append(links, map[string]string{"name": filepath.Base(asset), "url": web + result.FullPath})
+	}
+
+	body := map[string]interface{}{
+		"tag_name":    release.Tag,
+		"name":        release.Name,
+		"description": release.Body,
+	}
+	if len(links) > 0 {
+		body["assets"] = map[string]interface{}{"links": links}
+	}
+	result := struct {
+		Links struct {
+			Self string `json:"self"`
+		} `json:"_links"`
+	}{}
+	if err := f.api.json(ctx, http.MethodPost, project+"/releases", body, &result); err != nil {
+		return "", err
+	}
+	return result.Links.Self, nil
+}
+
+// Gitea REST API
+type giteaForge struct {
+	api  forgeAPI
+	repo string
+}
+
+func (f *giteaForge) CreateRelease(ctx context.Context, release ForgeRelease) (string, error) {
+	body := map[string]interface{}{
+		"tag_name":   release.Tag,
+		"name":       release.Name,
+		"body":       release.Body,
+		"draft":      release.Draft,
+		"prerelease": release.Prerelease,
+	}
+	result := struct {
+		ID      int64  `json:"id"`
+		HTMLURL string `json:"html_url"`
+	}{}
+	releases := f.api.url + "/repos/" + f.repo + "/releases"
+	if err := f.api.json(ctx, http.MethodPost, releases, body, &result); err != nil {
+		return "", err
+	}
+	for _, asset := range release.Assets {
+		log.Info("Upload release asset", log.F("asset", asset))
+		u := releases + "/" + strconv.FormatInt(result.ID, 10) + "/assets?name=" + url.QueryEscape(filepath.Base(asset))
+		if err := f.api.multipart(ctx, u, "attachment", asset, nil); err != nil {
+			return "", err
+		}
+	}
+	return result.HTMLURL, nil
+}
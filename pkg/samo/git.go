@@ -0,0 +1,69 @@
+package samo
+
+import (
+	"context"
+
+	"github.com/lorislab/samo/tools"
+)
+
+// Describe result of the git describe of the current commit
+type Describe = tools.GitDescribe
+
+// Git backend of the project
+type Git interface {
+	// Describe the last tag, number of commits since the tag and the commit hash
+	Describe(ctx context.Context) (Describe, error)
+	// DescribeExclude describe ignoring the tag
+	DescribeExclude(ctx context.Context, tag string) (Describe, error)
+	// Branch the current branch name
+	Branch(ctx context.Context) (string, error)
+	// LogMessages full commit messages with the body and trailers between the two revisions
+	LogMessages(ctx context.Context, from, to string) ([]string, error)
+	// Source remote origin url or the top level directory of the repository
+	Source(ctx context.Context) (string, error)
+}
+
+// GitBranches optional git backend extension listing the branches, required by the release branches
+type GitBranches interface {
+	// Branches names of the local and remote branches without the remote name
+	Branches(ctx context.Context) ([]string, error)
+}
+
+// CommandGit git backend using the git command line
+type CommandGit struct{}
+
+// NewCommandGit create git backend using the git command line
+func NewCommandGit() *CommandGit {
+	return &CommandGit{}
+}
+
+func (g *CommandGit) Describe(ctx context.Context) (Describe, error) {
+	return tools.GitDescribeInfo(ctx)
+}
+
+func (g *CommandGit) DescribeExclude(ctx context.Context, tag string) (Describe, error) {
+	return tools.GitDescribeExclude(ctx, tag)
+}
+
+func (g *CommandGit) Branch(ctx context.Context) (string, error) {
+	return tools.GitBranch(ctx)
+}
+
+func (g *CommandGit) LogMessages(ctx context.Context, from, to string) ([]string, error) {
+	return tools.GitLogMessages(ctx, from, to)
+}
+
+func (g *CommandGit) Branches(ctx context.Context) ([]string, error) {
+	return tools.GitBranches(ctx)
+}
+
+func (g *CommandGit) Source(ctx context.Context) (string, error) {
+	tmp, err := tools.Output(ctx, tools.NewCommand("git", "config", "remote.origin.url"))
+	if err != nil {
+		if ctx.Err() != nil {
+			return "", ctx.Err()
+		}
+		return tools.Output(ctx, tools.NewCommand("git", "rev-parse", "--show-toplevel"))
+	}
+	return tmp, nil
+}
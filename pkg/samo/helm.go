@@ -0,0 +1,73 @@
+package samo
+
+import (
+	"fmt"
+
+	"github.com/lorislab/samo/tools"
+)
+
+// HelmSign signing options of the helm chart package
+type HelmSign struct {
+	Key            string
+	Keyring        string
+	PassphraseFile string
+}
+
+// HelmPackage create the helm package command of the chart directory. Nil sign options skip the signing.
+func HelmPackage(dir string, sign *HelmSign) (Command, error) {
+	command := []string{"package"}
+	if sign != nil {
+		if len(sign.Key) == 0 {
+			return Command{}, fmt.Errorf("%w: missing key for the helm chart signing", tools.ErrInvalidInput)
+		}
+		command = append(command, "--sign", "--key", sign.Key)
+		if len(sign.Keyring) > 0 {
+			command = append(command, "--keyring", sign.Keyring)
+		}
+		if len(sign.PassphraseFile) > 0 {
+			command = append(command, "--passphrase-file", sign.PassphraseFile)
+		}
+	}
+	command = append(command, dir)
+	return Cmd("helm", command...), nil
+}
+
+// HelmDependency create the helm dependency command (build or update) of the chart directory
+func HelmDependency(action, dir string) Command {
+	return Cmd("helm", "dependency", action, dir)
+}
+
+// HelmPull helm pull operation of the chart
+type HelmPull struct {
+	// Chart reference, repository or registry with the chart name
+	Chart   string
+	Version string
+	Dir     string
+	// Verify the provenance file of the chart
+	Verify  bool
+	Keyring string
+}
+
+// Command create the helm pull command
+func (p HelmPull) Command() Command {
+	command := []string{"pull", p.Chart, "--version", p.Version}
+	if p.Verify {
+		command = append(command, "--verify")
+		if len(p.Keyring) > 0 {
+			command = append(command, "--keyring", p.Keyring)
+		}
+	}
+	command = append(command, "--untar", "--untardir", p.Dir)
+	return RetryCmd("helm", command...)
+}
+
+// HelmPush create the helm push command of the package to the OCI registry.
+// The helm push the provenance file next to the package automatically.
+func HelmPush(filename, registry string) Command {
+	return RetryCmd("helm", "push", filename, registry)
+}
+
+// HelmPackageFile helm package file name of the chart
+func HelmPackageFile(name, version string) string {
+	return name + `-` + version + `.tgz`
+}
@@ -0,0 +1,372 @@
+package samo
+
+import (
+	"context"
+	"path/filepath"
+	"regexp"
+	"strings"
+
+	"github.com/Masterminds/semver/v3"
+	"github.com/lorislab/samo/log"
+	"github.com/lorislab/samo/tools"
+)
+
+var sourceLinkRegex = regexp.MustCompile(`\/\/.*@`)
+
+// Options of the project
+type Options struct {
+	// Name of the project. Default name is created from the git source.
+	Name string
+	// FirstVersion version of the project without any tag
+	FirstVersion string
+	// VersionTemplate go template of the build version. Values: Tag,Hash,Count,Branch,Version
+	VersionTemplate string
+	// BranchTemplate go template of the patch branch name. Values: Major,Minor,Patch
+	BranchTemplate string
+	// ReleaseBranchTemplate go template of the release branch name. Values: Major,Minor,Patch.
+	// Empty template disables the release branches.
+	ReleaseBranchTemplate string
+	// Description of the project. Default value is the commit hash.
+	Description string
+	// URL of the project. Default value is created from the git source.
+	URL string
+	// Strategy of the next version. Default strategy is DefaultStrategy.
+	Strategy VersionStrategy
+}
+
+// Project common project interface
+type Project struct {
+	name        string
+	describe    Describe
+	rc          Describe
+	branch      string
+	source      string
+	url         string
+	description string
+	patchBuild  bool
+	// releaseBranch the current branch is the release branch
+	releaseBranch bool
+	explain       *Explanation
+	version       *semver.Version
+	rcVersion     *semver.Version
+	release       *semver.Version
+	rcRelease     *semver.Version
+}
+
+// Name project name
+func (g Project) Name() string {
+	return g.name
+}
+
+func (g Project) Source() string {
+	return g.source
+}
+
+func (g Project) Major() uint64 {
+	return g.version.Major()
+}
+
+func (g Project) Minor() uint64 {
+	return g.version.Minor()
+}
+
+func (g Project) Patch() uint64 {
+	return g.version.Patch()
+}
+
+func (g Project) Prerelease() string {
+	return g.version.Prerelease()
+}
+
+func (g Project) Version() string {
+	return g.version.String()
+}
+
+func (g Project) Description() string {
+	return g.description
+}
+
+func (g Project) Url() string {
+	return g.url
+}
+
+func (g Project) Release() string {
+	return g.release.String()
+}
+
+func (g Project) Hash() string {
+	return g.describe.Hash
+}
+
+func (g Project) Branch() string {
+	return g.branch
+}
+
+func (g Project) Count() string {
+	return g.describe.Count
+}
+
+func (g Project) Tag() string {
+	return g.describe.Tag
+}
+
+func (g Project) IsPatchBuild() bool {
+	return g.patchBuild
+}
+
+// Explain decision trace of the version calculation
+func (g Project) Explain() *Explanation {
+	return g.explain
+}
+
+// IsReleaseBranch the current branch is the release branch of the release branch template
+func (g Project) IsReleaseBranch() bool {
+	return g.releaseBranch
+}
+
+// SwitchBackToReleaseCandidate switch the version and release to the last release candidate of the tag
+func (g *Project) SwitchBackToReleaseCandidate() {
+	g.version = g.rcVersion
+	g.release = g.rcRelease
+}
+
+// New create project from the git repository
+func New(ctx context.Context, opts Options, git Git) (*Project, error) {
+
+	if opts.Strategy == nil {
+		opts.Strategy = DefaultStrategy{}
+	}
+
+	// read repository git url or directory name
+	tmp, err := git.Source(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	// create project source
+	source := sourceLinkRegex.ReplaceAllString(tmp, `//`)
+	log.Debug("Project", log.F("source", source))
+
+	// create project name
+	name := opts.Name
+	if len(name) == 0 {
+		name = "no-name"
+		tmp = strings.TrimSuffix(tmp, ".git")
+		tmp = filepath.Base(tmp)
+		if len(tmp) > 0 && tmp != "." && tmp != "/" {
+			name = tmp
+		}
+	}
+
+	describe, err := git.Describe(ctx)
+	if err != nil {
+		return nil, err
+	}
+	rc := describe
+
+	branch, err := git.Branch(ctx)
+	if err != nil {
+		return nil, err
+	}
+	patchBuild := false
+	explain := &Explanation{Tag: describe.Tag, Count: describe.Count, Hash: describe.Hash, Branch: branch, VersionTemplate: opts.VersionTemplate}
+	explain.step("git describe: tag '%s', %s commits since the tag, hash %s", describe.Tag, describe.Count, describe.Hash)
+
+	// release branch X.Y has the release version X.Y.0
+	var releaseVer *semver.Version
+	if len(opts.ReleaseBranchTemplate) > 0 {
+		if releaseVer, err = ReleaseBranchVersion(branch, opts.ReleaseBranchTemplate); err != nil {
+			return nil, err
+		}
+		if releaseVer != nil {
+			explain.ReleaseBranch = releaseVer.String()
+			explain.step("branch %s is release branch of the release %s (template %s)", branch, releaseVer.String(), opts.ReleaseBranchTemplate)
+		}
+	}
+
+	version := opts.FirstVersion
+	lastRC := version
+	if len(describe.Tag) == 0 {
+		explain.FirstVersion = version
+		explain.step("no tag found, first version %s", version)
+	}
+
+	// check for empty repository
+	if len(describe.Tag) > 0 {
+		ver, err := tools.CreateSemVer(describe.Tag)
+		if err != nil {
+			return nil, err
+		}
+		patchBranch, err := PatchBranchName(ver, opts.BranchTemplate)
+		if err != nil {
+			return nil, err
+		}
+
+		// branch name is patch branch or version is patch
+		patchBuild = (branch == patchBranch) || ver.Patch() > 0
+		// release branch after the release X.Y.0 creates the patch versions
+		if releaseVer != nil && ver.Major() == releaseVer.Major() && ver.Minor() == releaseVer.Minor() {
+			patchBuild = true
+		}
+		explain.PatchBranch = patchBranch
+		explain.PatchBuild = patchBuild
+		switch {
+		case branch == patchBranch:
+			explain.PatchBuildReason = "branch " + branch + " is patch branch of the tag " + describe.Tag
+		case ver.Patch() > 0:
+			explain.PatchBuildReason = "tag " + describe.Tag + " is patch version"
+		case patchBuild:
+			explain.PatchBuildReason = "tag " + describe.Tag + " is release of the release branch " + branch
+		}
+		if patchBuild {
+			explain.step("patch build: %s", explain.PatchBuildReason)
+		} else {
+			explain.step("no patch build: branch %s is not patch branch %s (template %s) and tag %s is not patch version", branch, patchBranch, opts.BranchTemplate, describe.Tag)
+		}
+
+		log.Debug("Branch", log.Fields{"branch": branch, "patchBranch": patchBranch, "patchBuild": patchBuild, "count": describe.Count})
+
+		// create version
+		explain.Strategy = &StrategyExplanation{}
+		version, err = opts.Strategy.Next(ctx, VersionRequest{Git: git, Version: ver, PatchBuild: patchBuild, Describe: describe, Explain: explain.Strategy})
+		if err != nil {
+			return nil, err
+		}
+		explain.Strategy.defaultName(opts.Strategy)
+		explain.step("strategy %s: %s bump of the tag %s to %s, %s", explain.Strategy.Name, explain.Strategy.Bump, describe.Tag, version, explain.Strategy.Reason)
+		if len(explain.Strategy.Commit) > 0 {
+			explain.step("deciding commit: %s", explain.Strategy.Commit)
+		}
+		switch {
+		case patchBuild:
+			// patch version of the strategy
+		case releaseVer != nil:
+			version = releaseVer.String()
+			explain.step("release branch %s: release version %s", branch, version)
+		case len(opts.ReleaseBranchTemplate) > 0:
+			next, err := nextAfterReleaseBranches(ctx, git, version, opts.ReleaseBranchTemplate)
+			if err != nil {
+				return nil, err
+			}
+			if next != version {
+				explain.step("release branch of the version %s exists: next minor version %s", version, next)
+			}
+			version = next
+		}
+
+		// check last rc version
+		if describe.Count == "0" {
+
+			// find last tag before release
+			rc, err = git.DescribeExclude(ctx, describe.Tag)
+			if err != nil {
+				return nil, err
+			}
+			if len(rc.Tag) > 0 {
+				rcVer, err := tools.CreateSemVer(rc.Tag)
+				if err != nil {
+					return nil, err
+				}
+				rcExplain := &StrategyExplanation{}
+				lastRC, err = opts.Strategy.Next(ctx, VersionRequest{Git: git, Version: rcVer, PatchBuild: patchBuild, Describe: rc, Previous: true, Explain: rcExplain})
+				if err != nil {
+					return nil, err
+				}
+				// release candidate of the release branch or after the existing release branches
+				switch {
+				case releaseVer != nil && (rcVer.Major() != releaseVer.Major() || rcVer.Minor() != releaseVer.Minor()):
+					lastRC = releaseVer.String()
+				case !patchBuild && releaseVer == nil && len(opts.ReleaseBranchTemplate) > 0:
+					if lastRC, err = nextAfterReleaseBranches(ctx, git, lastRC, opts.ReleaseBranchTemplate); err != nil {
+						return nil, err
+					}
+				}
+				rcExplain.defaultName(opts.Strategy)
+				explain.ReleaseCandidate = &ReleaseCandidateExplanation{Tag: rc.Tag, Count: rc.Count, Hash: rc.Hash, Release: lastRC, Strategy: rcExplain}
+				explain.step("HEAD is tagged: release candidate from the previous tag %s (%s commits), %s bump to %s", rc.Tag, rc.Count, rcExplain.Bump, lastRC)
+			} else {
+				explain.step("HEAD is tagged: no previous tag, release candidate %s", lastRC)
+			}
+		} else {
+			lastRC = version
+		}
+	}
+
+	var url = opts.URL
+	if len(url) == 0 {
+		// remove .git suffix
+		url = strings.TrimSuffix(source, ".git")
+
+		// replace git@server:path -> https://server/path
+		if strings.HasPrefix(url, "git@") {
+			url = strings.TrimPrefix(url, "git@")
+			url = strings.Replace(url, ":", "/", 1)
+			url = "https://" + url
+		}
+
+	}
+
+	var description = opts.Description
+	if len(description) == 0 {
+		description = describe.Hash
+	}
+
+	p := &Project{
+		name:        name,
+		describe:    describe,
+		branch:      branch,
+		source:      source,
+		description: description,
+		patchBuild:  patchBuild,
+		url:         url,
+		rc:          rc,
+
+		releaseBranch: releaseVer != nil,
+	}
+	if p.rcVersion, err = TemplateVersion(lastRC, branch, opts.VersionTemplate, rc); err != nil {
+		return nil, err
+	}
+	if p.rcRelease, err = tools.CreateSemVer(lastRC); err != nil {
+		return nil, err
+	}
+	if p.version, err = TemplateVersion(version, branch, opts.VersionTemplate, describe); err != nil {
+		return nil, err
+	}
+	if p.release, err = tools.CreateSemVer(version); err != nil {
+		return nil, err
+	}
+	explain.NextVersion = version
+	explain.Version = p.Version()
+	explain.Release = p.Release()
+	if explain.ReleaseCandidate != nil {
+		explain.ReleaseCandidate.Version = p.rcVersion.String()
+	}
+	explain.step("version template %s: version %s, release %s", opts.VersionTemplate, p.Version(), p.Release())
+	p.explain = explain
+	log.Debug("Versions", log.Fields{"version": p.Version(), "release": p.Release(), "rcVersion": p.rcVersion.String(), "rcRelease": p.rcRelease.String()})
+	return p, nil
+}
+
+// PatchBranchName create the patch branch name of the version
+func PatchBranchName(version *semver.Version, template string) (string, error) {
+	return tools.Template(version, template)
+}
+
+// TemplateVersion create the build version from the version template
+func TemplateVersion(version, branch, template string, describe Describe) (*semver.Version, error) {
+	data := struct {
+		Tag, Hash, Count, Branch, Version string
+	}{
+		Tag:     describe.Tag,
+		Hash:    describe.Hash,
+		Count:   describe.Count,
+		Branch:  branch,
+		Version: version,
+	}
+
+	tmp, err := tools.Template(data, template)
+	if err != nil {
+		return nil, err
+	}
+	return tools.CreateSemVer(tmp)
+}
@@ -0,0 +1,181 @@
+package samo
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/lorislab/samo/log"
+	"github.com/lorislab/samo/tools"
+)
+
+// RegistryAuth sources of the registry credentials. The sources are resolved in the order:
+// environment variables <EnvPrefix>_USERNAME, <EnvPrefix>_PASSWORD or <EnvPrefix>_PASSWORD_FILE,
+// the username and the password file, the docker credential helper.
+type RegistryAuth struct {
+	// Registry host or image reference of the registry, for example ghcr.io/lorislab or oci://ghcr.io/lorislab/charts
+	Registry string
+	// EnvPrefix prefix of the environment variables, for example SAMO_DOCKER
+	EnvPrefix string
+	// Username of the registry
+	Username string
+	// PasswordFile file which contains the password of the registry
+	PasswordFile string
+	// CredentialHelper name of the docker credential helper, the command docker-credential-<name>
+	CredentialHelper string
+}
+
+// RegistryCredentials resolved credentials of the registry
+type RegistryCredentials struct {
+	Host     string
+	Username string
+	Password string
+}
+
+// RegistryHost host of the registry or the image reference
+func RegistryHost(registry string) string {
+	registry = strings.TrimPrefix(registry, "oci://")
+	return strings.SplitN(registry, "/", 2)[0]
+}
+
+// Credentials resolve the credentials of the registry, returns nil if no source has the credentials.
+// The password is registered as the secret of the log output.
+func (a RegistryAuth) Credentials(ctx context.Context) (*RegistryCredentials, error) {
+	host := RegistryHost(a.Registry)
+	if len(host) == 0 {
+		return nil, nil
+	}
+	username := a.Username
+	password := ""
+	passwordFile := a.PasswordFile
+	if len(a.EnvPrefix) > 0 {
+		if v := os.Getenv(a.EnvPrefix + "_USERNAME"); len(v) > 0 {
+			username = v
+		}
+		password = os.Getenv(a.EnvPrefix + "_PASSWORD")
+		if v := os.Getenv(a.EnvPrefix + "_PASSWORD_FILE"); len(v) > 0 && len(password) == 0 {
+			passwordFile = v
+		}
+	}
+	if len(password) == 0 && len(passwordFile) > 0 {
+		data, err := os.ReadFile(passwordFile)
+		if err != nil {
+			return nil, fmt.Errorf("%w: error read registry password file %s: %v", tools.ErrInvalidInput, passwordFile, err)
+		}
+		password = strings.TrimRight(string(data), "\r\n")
+	}
+	if len(password) == 0 && len(a.CredentialHelper) > 0 {
+		return credentialHelper(ctx, a.CredentialHelper, host)
+	}
+	if len(password) == 0 {
+		return nil, nil
+	}
+	if len(username) == 0 {
+		return nil, fmt.Errorf("%w: missing username of the registry %s", tools.ErrInvalidInput, host)
+	}
+	log.AddSecret(password)
+	return &RegistryCredentials{Host: host, Username: username, Password: password}, nil
+}
+
+// credentialHelper get the credentials of the host from the docker credential helper
+func credentialHelper(ctx context.Context, helper, host string) (*RegistryCredentials, error) {
+	c := Cmd("docker-credential-"+helper, "get")
+	c.Stdin = strings.NewReader(host)
+	c.Quiet = true
+	out, err := tools.Output(ctx, c)
+	if err != nil {
+		return nil, fmt.Errorf("%w: error get credentials of the registry %s from the helper %s: %v", tools.ErrPrecondition, host, helper, err)
+	}
+	result := struct {
+		Username string `json:"Username"`
+		Secret   string `json:"Secret"`
+	}{}
+	if err := json.Unmarshal([]byte(out), &result); err != nil {
+		return nil, fmt.Errorf("%w: error read credentials of the registry %s from the helper %s: %v", tools.ErrPrecondition, host, helper, err)
+	}
+	if len(result.Secret) == 0 {
+		return nil, nil
+	}
+	log.AddSecret(result.Secret)
+	return &RegistryCredentials{Host: host, Username: result.Username, Password: result.Secret}, nil
+}
+
+// DockerLogin create the docker login command, the password is written to the standard input
+func DockerLogin(c RegistryCredentials) Command {
+	cmd := Cmd("docker", "login", c.Host, "--username", c.Username, "--password-stdin")
+	cmd.Stdin = strings.NewReader(c.Password)
+	return cmd
+}
+
+// HelmRegistryLogin create the helm registry login command, the password is written to the standard input
+func HelmRegistryLogin(c RegistryCredentials) Command {
+	cmd := Cmd("helm", "registry", "login", c.Host, "--username", c.Username, "--password-stdin")
+	cmd.Stdin = strings.NewReader(c.Password)
+	return cmd
+}
+
+// dockerConfigLinks directories of the docker config which are linked to the isolated config
+var dockerConfigLinks = []string{"cli-plugins", "buildx", "contexts"}
+
+// RegistryConfig isolated temporary config directory of the docker and helm registry credentials.
+// The environment variables DOCKER_CONFIG and HELM_REGISTRY_CONFIG point to the directory until it is closed.
+type RegistryConfig struct {
+	Dir string
+	env map[string]*string
+}
+
+// NewRegistryConfig create the isolated registry config directory and set the environment variables of the process
+func NewRegistryConfig() (*RegistryConfig, error) {
+	dir, err := os.MkdirTemp("", "samo-registry-")
+	if err != nil {
+		return nil, fmt.Errorf("error create registry config directory: %w", err)
+	}
+	rc := &RegistryConfig{Dir: dir, env: map[string]*string{}}
+
+	// keep the docker plugins, the buildx builders and the contexts of the user docker config
+	original := os.Getenv("DOCKER_CONFIG")
+	if len(original) == 0 {
+		if home, err := os.UserHomeDir(); err == nil {
+			original = filepath.Join(home, ".docker")
+		}
+	}
+	for _, name := range dockerConfigLinks {
+		src := filepath.Join(original, name)
+		if _, err := os.Stat(src); len(original) == 0 || err != nil {
+			continue
+		}
+		if err := os.Symlink(src, filepath.Join(dir, name)); err != nil {
+			log.Warn("Error link docker config directory", log.F("dir", src).E(err))
+		}
+	}
+
+	rc.setEnv("DOCKER_CONFIG", dir)
+	rc.setEnv("HELM_REGISTRY_CONFIG", filepath.Join(dir, "helm-registry.json"))
+	log.Debug("Isolated registry config", log.F("dir", dir))
+	return rc, nil
+}
+
+func (rc *RegistryConfig) setEnv(key, value string) {
+	if v, exists := os.LookupEnv(key); exists {
+		rc.env[key] = &v
+	} else {
+		rc.env[key] = nil
+	}
+	_ = os.Setenv(key, value)
+}
+
+// Close restore the environment variables and remove the config directory with the credentials
+func (rc *RegistryConfig) Close() error {
+	for key, value := range rc.env {
+		if value == nil {
+			_ = os.Unsetenv(key)
+		} else {
+			_ = os.Setenv(key, *value)
+		}
+	}
+	log.Debug("Remove isolated registry config", log.F("dir", rc.Dir))
+	return os.RemoveAll(rc.Dir)
+}
@@ -0,0 +1,98 @@
+package samo
+
+import (
+	"context"
+	"fmt"
+	"regexp"
+	"strings"
+
+	"github.com/Masterminds/semver/v3"
+	"github.com/lorislab/samo/log"
+	"github.com/lorislab/samo/tools"
+)
+
+// release branch template placeholders of the version parts
+const (
+	releaseBranchMajor = "\x00major\x00"
+	releaseBranchMinor = "\x00minor\x00"
+	releaseBranchPatch = "\x00patch\x00"
+)
+
+// ReleaseBranchName create the release branch name of the version
+func ReleaseBranchName(version *semver.Version, template string) (string, error) {
+	return tools.Template(version, template)
+}
+
+// ReleaseBranchVersion parse the version X.Y.0 from the release branch name.
+// Returns nil if the branch is not release branch of the template.
+func ReleaseBranchVersion(branch, template string) (*semver.Version, error) {
+	reg, err := releaseBranchRegex(template)
+	if err != nil {
+		return nil, err
+	}
+	return releaseBranchVersion(reg, branch)
+}
+
+// releaseBranchRegex create the regular expression of the release branch template
+func releaseBranchRegex(template string) (*regexp.Regexp, error) {
+	data := struct{ Major, Minor, Patch string }{Major: releaseBranchMajor, Minor: releaseBranchMinor, Patch: releaseBranchPatch}
+	tmp, err := tools.Template(data, template)
+	if err != nil {
+		return nil, err
+	}
+	if !strings.Contains(tmp, releaseBranchMajor) || !strings.Contains(tmp, releaseBranchMinor) {
+		return nil, fmt.Errorf("%w: release branch template %s must contain Major and Minor", tools.ErrInvalidInput, template)
+	}
+	expr := strings.NewReplacer(
+		releaseBranchMajor, `(?P<major>\d+)`,
+		releaseBranchMinor, `(?P<minor>\d+)`,
+		releaseBranchPatch, `\d+`,
+	).Replace(regexp.QuoteMeta(tmp))
+	return regexp.Compile("^" + expr + "$")
+}
+
+func releaseBranchVersion(reg *regexp.Regexp, branch string) (*semver.Version, error) {
+	match := reg.FindStringSubmatch(branch)
+	if match == nil {
+		return nil, nil
+	}
+	major := match[reg.SubexpIndex("major")]
+	minor := match[reg.SubexpIndex("minor")]
+	return tools.CreateSemVer(major + "." + minor + ".0")
+}
+
+// nextAfterReleaseBranches next minor version after the existing release branches.
+// The version of the main line is increased if the release branch of the version or newer exists.
+// The version is not changed if the git backend does not implement GitBranches.
+func nextAfterReleaseBranches(ctx context.Context, git Git, version, template string) (string, error) {
+	gb, ok := git.(GitBranches)
+	if !ok {
+		log.Debug("Git backend does not list the branches, skip release branches", log.F("version", version))
+		return version, nil
+	}
+	ver, err := tools.CreateSemVer(version)
+	if err != nil {
+		return "", err
+	}
+	reg, err := releaseBranchRegex(template)
+	if err != nil {
+		return "", err
+	}
+	branches, err := gb.Branches(ctx)
+	if err != nil {
+		return "", err
+	}
+	result := *ver
+	for _, branch := range branches {
+		rv, err := releaseBranchVersion(reg, branch)
+		if err != nil {
+			return "", err
+		}
+		if rv == nil || rv.LessThan(&result) {
+			continue
+		}
+		result = rv.IncMinor()
+		log.Debug("Release branch exists, next minor version", log.F("branch", branch).F("version", result.String()))
+	}
+	return result.String(), nil
+}
@@ -0,0 +1,191 @@
+package samo
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/Masterminds/semver/v3"
+	"github.com/lorislab/samo/log"
+	"github.com/lorislab/samo/tools"
+	cc "gitlab.com/digitalxero/go-conventional-commit"
+)
+
+// VersionRequest input of the next version calculation
+type VersionRequest struct {
+	// Git backend of the project
+	Git Git
+	// Version of the last tag
+	Version *semver.Version
+	// PatchBuild the build is on the patch branch or from the patch version
+	PatchBuild bool
+	// Describe of the last tag
+	Describe Describe
+	// Previous calculation of the previous release candidate, the release flags are not applied
+	Previous bool
+	// Explain optional decision of the strategy, filled by the strategy
+	Explain *StrategyExplanation
+}
+
+// VersionStrategy calculate the next version of the project from the last tag
+type VersionStrategy interface {
+	Next(ctx context.Context, req VersionRequest) (string, error)
+}
+
+// DefaultStrategy next minor version, major or patch version on request
+type DefaultStrategy struct {
+	Major bool
+	Patch bool
+	// ReleaseAs read the commits since the tag for the Release-As trailer
+	ReleaseAs bool
+}
+
+func (s DefaultStrategy) Next(ctx context.Context, req VersionRequest) (string, error) {
+	ver := req.Version
+	major := s.Major && !req.Previous
+	patch := s.Patch && !req.Previous
+
+	if s.ReleaseAs && !req.PatchBuild && req.Describe.Count != "0" && req.Git != nil {
+		commits, err := req.Git.LogMessages(ctx, tagRevision(req), "HEAD")
+		if err != nil {
+			return "", err
+		}
+		if releaseAs, err := releaseAsVersion("default", ver, commits, req.Explain); err != nil || len(releaseAs) > 0 {
+			return releaseAs, err
+		}
+	}
+
+	if req.PatchBuild || patch || ver.Patch() != 0 {
+		switch {
+		case req.PatchBuild:
+			req.Explain.decide("default", "patch", "patch build")
+		case patch:
+			req.Explain.decide("default", "patch", "release patch flag")
+		default:
+			req.Explain.decide("default", "patch", "tag "+ver.Original()+" is patch version")
+		}
+		tmp := ver.IncPatch()
+		return tmp.String(), nil
+	}
+	if major {
+		if ver.Patch() != 0 {
+			return "", fmt.Errorf("%w: can not created major release from the patch version %s", tools.ErrPrecondition, ver.String())
+		}
+		req.Explain.decide("default", "major", "release major flag")
+		tmp := ver.IncMajor()
+		return tmp.String(), nil
+	}
+	req.Explain.decide("default", "minor", "default next minor version")
+	tmp := ver.IncMinor()
+	return tmp.String(), nil
+}
+
+// ConventionalCommitsStrategy next version based on the conventional commits since the last tag
+type ConventionalCommitsStrategy struct{}
+
+func (s ConventionalCommitsStrategy) Next(ctx context.Context, req VersionRequest) (string, error) {
+	ver := req.Version
+
+	// for patch branch we can ignore conventional commits
+	if req.PatchBuild {
+		req.Explain.decide("conventional-commits", "patch", "patch build")
+		tmp := ver.IncPatch()
+		return tmp.String(), nil
+	}
+
+	if req.Describe.Count == "0" {
+		req.Explain.decide("conventional-commits", "minor", "no commits since the tag "+req.Describe.Tag)
+		tmp := ver.IncMinor()
+		return tmp.String(), nil
+	}
+
+	commits, err := req.Git.LogMessages(ctx, tagRevision(req), "HEAD")
+	if err != nil {
+		return "", err
+	}
+	if req.Explain != nil {
+		for _, c := range commits {
+			req.Explain.Commits = append(req.Explain.Commits, CommitHeader(c))
+		}
+	}
+
+	if releaseAs, err := releaseAsVersion("conventional-commits", ver, commits, req.Explain); err != nil || len(releaseAs) > 0 {
+		return releaseAs, err
+	}
+
+	commit := FindConventionalCommit(commits)
+	if commit != nil && commit.Major {
+		req.Explain.decide("conventional-commits", "major", "breaking change")
+		if req.Explain != nil {
+			req.Explain.Commit = findCommit(commits, func(c *cc.ConventionalCommit) bool { return c.Major })
+		}
+		tmp := ver.IncMajor()
+		return tmp.String(), nil
+	}
+	if commit != nil && commit.Minor {
+		req.Explain.decide("conventional-commits", "minor", "new feature")
+		if req.Explain != nil {
+			req.Explain.Commit = findCommit(commits, func(c *cc.ConventionalCommit) bool { return c.Minor })
+		}
+	} else {
+		req.Explain.decide("conventional-commits", "minor", fmt.Sprintf("no breaking change in %d commits", len(commits)))
+	}
+	tmp := ver.IncMinor()
+	return tmp.String(), nil
+}
+
+// tagRevision git revision of the last tag as written, the version drops the v prefix of the tag
+func tagRevision(req VersionRequest) string {
+	if len(req.Describe.Tag) > 0 {
+		return req.Describe.Tag
+	}
+	return req.Version.Original()
+}
+
+// releaseAsVersion the version of the Release-As trailer, which forces the release version.
+// Returns empty string if no commit has the trailer.
+func releaseAsVersion(strategy string, ver *semver.Version, commits []string, explain *StrategyExplanation) (string, error) {
+	releaseAs, releaseAsCommit, err := ReleaseAs(commits)
+	if err != nil || releaseAs == nil {
+		return "", err
+	}
+	if !releaseAs.GreaterThan(ver) {
+		return "", fmt.Errorf("%w: %s version %s of the commit '%s' must be greater than the tag %s",
+			tools.ErrPrecondition, ReleaseAsTrailer, releaseAs.String(), CommitHeader(releaseAsCommit), ver.Original())
+	}
+	explain.decide(strategy, "release-as", ReleaseAsTrailer+" trailer")
+	if explain != nil {
+		explain.Commit = CommitHeader(releaseAsCommit)
+	}
+	return releaseAs.String(), nil
+}
+
+// findCommit the header of the first commit matching the conventional commit
+func findCommit(commits []string, match func(*cc.ConventionalCommit) bool) string {
+	for _, c := range commits {
+		if match(ParseCommit(c)) {
+			return CommitHeader(c)
+		}
+	}
+	return ""
+}
+
+// FindConventionalCommit find the commit with the highest impact, the commits are the full messages
+// with the body and footers. Returns nil for empty list.
+func FindConventionalCommit(commits []string) *cc.ConventionalCommit {
+	var result *cc.ConventionalCommit
+	for _, commit := range commits {
+		item := ParseCommit(commit)
+		if item.Major {
+			log.Debug("Major", log.F("commit", item))
+			return item
+		}
+		if result == nil {
+			result = item
+		} else {
+			if !result.Minor {
+				result = item
+			}
+		}
+	}
+	return result
+}
@@ -0,0 +1,86 @@
+package samo
+
+import (
+	"context"
+	"testing"
+
+	"github.com/Masterminds/semver/v3"
+)
+
+// logGit git backend with the fixed commit messages, records the revisions of the log
+type logGit struct {
+	messages []string
+	from     []string
+}
+
+func (g *logGit) Describe(_ context.Context) (Describe, error) {
+	return Describe{}, nil
+}
+
+func (g *logGit) DescribeExclude(_ context.Context, _ string) (Describe, error) {
+	return Describe{}, nil
+}
+
+func (g *logGit) Branch(_ context.Context) (string, error) {
+	return "main", nil
+}
+
+func (g *logGit) LogMessages(_ context.Context, from, _ string) ([]string, error) {
+	g.from = append(g.from, from)
+	return g.messages, nil
+}
+
+func (g *logGit) Source(_ context.Context) (string, error) {
+	return "", nil
+}
+
+func versionRequest(t *testing.T, git Git, tag, count string) VersionRequest {
+	t.Helper()
+	ver, err := semver.NewVersion(tag)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return VersionRequest{Git: git, Version: ver, Describe: Describe{Tag: tag, Count: count, Hash: "abc"}, Explain: &StrategyExplanation{}}
+}
+
+func TestStrategyTagPrefix(t *testing.T) {
+	tests := []struct {
+		name     string
+		strategy VersionStrategy
+		messages []string
+		expected string
+	}{
+		{name: "conventional commits", strategy: ConventionalCommitsStrategy{}, messages: []string{"feat: a"}, expected: "1.1.0"},
+		{name: "conventional commits release-as", strategy: ConventionalCommitsStrategy{}, messages: []string{"fix: a\n\nRelease-As: 2.0.0"}, expected: "2.0.0"},
+		{name: "default release-as", strategy: DefaultStrategy{ReleaseAs: true}, messages: []string{"fix: a\n\nRelease-As: 2.0.0"}, expected: "2.0.0"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			git := &logGit{messages: tt.messages}
+			version, err := tt.strategy.Next(context.Background(), versionRequest(t, git, "v1.0.0", "2"))
+			if err != nil {
+				t.Fatal(err)
+			}
+			if version != tt.expected {
+				t.Errorf("version %s, expected %s", version, tt.expected)
+			}
+			if len(git.from) != 1 || git.from[0] != "v1.0.0" {
+				t.Errorf("git log from %v, expected the tag v1.0.0", git.from)
+			}
+		})
+	}
+}
+
+func TestDefaultStrategySkipLog(t *testing.T) {
+	git := &logGit{messages: []string{"fix: a\n\nRelease-As: 2.0.0"}}
+	version, err := DefaultStrategy{}.Next(context.Background(), versionRequest(t, git, "v1.0.0", "2"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if version != "1.1.0" {
+		t.Errorf("version %s, expected 1.1.0", version)
+	}
+	if len(git.from) > 0 {
+		t.Errorf("default strategy without the Release-As trailer read the git log %v", git.from)
+	}
+}
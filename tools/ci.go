@@ -0,0 +1,345 @@
+package tools
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/lorislab/samo/log"
+)
+
+// CIInfo build information of the CI provider
+type CIInfo struct {
+	Provider    string
+	Branch      string
+	Tag         string
+	PullRequest string
+	BuildNumber string
+	Commit      string
+}
+
+// CIExport exported values of the project
+type CIExport struct {
+	// Values key value pairs, the keys are lower case with '-' separator
+	Values map[string]string
+	// EnvPrefix prefix of the environment variable names
+	EnvPrefix string
+	// DotEnvFile file of the dotenv report
+	DotEnvFile string
+	// Stdout output of the CI log commands
+	Stdout io.Writer
+}
+
+// CIProvider continuous integration provider
+type CIProvider interface {
+	// Name of the provider
+	Name() string
+	// Detect the build runs in the provider
+	Detect() bool
+	// Info build information from the environment
+	Info() CIInfo
+	// Export the values in the native format of the provider
+	Export(export CIExport) error
+}
+
+// ciProviders supported providers in the detection order
+var ciProviders = []CIProvider{
+	githubProvider{},
+	gitlabProvider{},
+	azureProvider{},
+	bitbucketProvider{},
+	jenkinsProvider{},
+	tektonProvider{},
+}
+
+// DetectCI detect the CI provider, returns nil for the local build
+func DetectCI() CIProvider {
+	for _, p := range ciProviders {
+		if p.Detect() {
+			return p
+		}
+	}
+	return nil
+}
+
+// CIProviderByName find the provider by the name
+func CIProviderByName(name string) (CIProvider, error) {
+	var names []string
+	for _, p := range ciProviders {
+		if p.Name() == name {
+			return p, nil
+		}
+		names = append(names, p.Name())
+	}
+	return nil, fmt.Errorf("%w: not supported CI provider %s, supported providers: %s", ErrInvalidInput, name, strings.Join(names, ","))
+}
+
+// parseGitRef parse git reference refs/heads/<branch>, refs/tags/<tag> or refs/pull/<id>/merge
+func parseGitRef(ref string, info *CIInfo) {
+	switch {
+	case strings.HasPrefix(ref, "refs/heads/"):
+		info.Branch = strings.TrimPrefix(ref, "refs/heads/")
+	case strings.HasPrefix(ref, "refs/tags/"):
+		info.Tag = strings.TrimPrefix(ref, "refs/tags/")
+	case strings.HasPrefix(ref, "refs/pull/"):
+		info.PullRequest = strings.SplitN(strings.TrimPrefix(ref, "refs/pull/"), "/", 2)[0]
+	default:
+		info.Branch = ref
+	}
+}
+
+// envName create environment variable name of the key
+func envName(prefix, key string) string {
+	return prefix + strings.ToUpper(strings.NewReplacer("-", "_", ".", "_").Replace(key))
+}
+
+func sortedKeys(values map[string]string) []string {
+	keys := make([]string, 0, len(values))
+	for k := range values {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// appendToFile append the text to the file, the file is created if it does not exist
+func appendToFile(filename, text string) error {
+	f, err := os.OpenFile(filename, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0644)
+	if err != nil {
+		return fmt.Errorf("error open file %s: %w", filename, err)
+	}
+	defer f.Close()
+	if _, err := f.WriteString(text); err != nil {
+		return fmt.Errorf("error write file %s: %w", filename, err)
+	}
+	return nil
+}
+
+// writeDotEnv write the values in the dotenv format
+func writeDotEnv(export CIExport) error {
+	if len(export.DotEnvFile) == 0 {
+		return fmt.Errorf("%w: missing dotenv file", ErrInvalidInput)
+	}
+	var sb strings.Builder
+	for _, k := range sortedKeys(export.Values) {
+		sb.WriteString(envName(export.EnvPrefix, k) + "=" + export.Values[k] + "\n")
+	}
+	if err := WriteToFile(export.DotEnvFile, sb.String()); err != nil {
+		return err
+	}
+	log.Info("Export dotenv file", log.F("file", export.DotEnvFile))
+	return nil
+}
+
+// GitHub Actions
+type githubProvider struct{}
+
+func (p githubProvider) Name() string {
+	return "github"
+}
+
+func (p githubProvider) Detect() bool {
+	return os.Getenv("GITHUB_ACTIONS") == "true"
+}
+
+func (p githubProvider) Info() CIInfo {
+	info := CIInfo{Provider: p.Name(), BuildNumber: os.Getenv("GITHUB_RUN_NUMBER"), Commit: os.Getenv("GITHUB_SHA")}
+	parseGitRef(os.Getenv("GITHUB_REF"), &info)
+	if len(info.PullRequest) > 0 {
+		info.Branch = os.Getenv("GITHUB_HEAD_REF")
+	}
+	return info
+}
+
+func (p githubProvider) Export(export CIExport) error {
+	if output := os.Getenv("GITHUB_OUTPUT"); len(output) > 0 {
+		var sb strings.Builder
+		for _, k := range sortedKeys(export.Values) {
+			v := export.Values[k]
+			if strings.Contains(v, "\n") {
+				sb.WriteString(k + "<<SAMO_EOF\n" + v + "\nSAMO_EOF\n")
+			} else {
+				sb.WriteString(k + "=" + v + "\n")
+			}
+		}
+		if err := appendToFile(output, sb.String()); err != nil {
+			return err
+		}
+		log.Info("Export GitHub step outputs", log.F("file", output))
+	}
+	if summary := os.Getenv("GITHUB_STEP_SUMMARY"); len(summary) > 0 {
+		var sb strings.Builder
+		sb.WriteString("### Samo project\n\n| Key | Value |\n|-----|-------|\n")
+		for _, k := range sortedKeys(export.Values) {
+			sb.WriteString("| " + k + " | `" + export.Values[k] + "` |\n")
+		}
+		if err := appendToFile(summary, sb.String()); err != nil {
+			return err
+		}
+		log.Info("Export GitHub step summary", log.F("file", summary))
+	}
+	return nil
+}
+
+// GitLab CI
+type gitlabProvider struct{}
+
+func (p gitlabProvider) Name() string {
+	return "gitlab"
+}
+
+func (p gitlabProvider) Detect() bool {
+	return os.Getenv("GITLAB_CI") == "true"
+}
+
+func (p gitlabProvider) Info() CIInfo {
+	info := CIInfo{
+		Provider:    p.Name(),
+		Branch:      os.Getenv("CI_COMMIT_BRANCH"),
+		Tag:         os.Getenv("CI_COMMIT_TAG"),
+		PullRequest: os.Getenv("CI_MERGE_REQUEST_IID"),
+		BuildNumber: os.Getenv("CI_PIPELINE_IID"),
+		Commit:      os.Getenv("CI_COMMIT_SHA"),
+	}
+	if len(info.PullRequest) > 0 {
+		info.Branch = os.Getenv("CI_MERGE_REQUEST_SOURCE_BRANCH_NAME")
+	}
+	return info
+}
+
+// Export write the dotenv report, use it in the job artifacts:reports:dotenv
+func (p gitlabProvider) Export(export CIExport) error {
+	return writeDotEnv(export)
+}
+
+// Azure Pipelines
+type azureProvider struct{}
+
+func (p azureProvider) Name() string {
+	return "azure"
+}
+
+func (p azureProvider) Detect() bool {
+	return strings.EqualFold(os.Getenv("TF_BUILD"), "true")
+}
+
+func (p azureProvider) Info() CIInfo {
+	info := CIInfo{Provider: p.Name(), BuildNumber: os.Getenv("BUILD_BUILDNUMBER"), Commit: os.Getenv("BUILD_SOURCEVERSION")}
+	parseGitRef(os.Getenv("BUILD_SOURCEBRANCH"), &info)
+	if pr := os.Getenv("SYSTEM_PULLREQUEST_PULLREQUESTNUMBER"); len(pr) > 0 {
+		info.PullRequest = pr
+	}
+	if len(info.PullRequest) > 0 {
+		info.Branch = strings.TrimPrefix(os.Getenv("SYSTEM_PULLREQUEST_SOURCEBRANCH"), "refs/heads/")
+	}
+	return info
+}
+
+// Export print the logging commands to set the output variables
+func (p azureProvider) Export(export CIExport) error {
+	for _, k := range sortedKeys(export.Values) {
+		if _, err := fmt.Fprintf(export.Stdout, "##vso[task.setvariable variable=%s;isOutput=true]%s\n", envName(export.EnvPrefix, k), export.Values[k]); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Bitbucket Pipelines
+type bitbucketProvider struct{}
+
+func (p bitbucketProvider) Name() string {
+	return "bitbucket"
+}
+
+func (p bitbucketProvider) Detect() bool {
+	return len(os.Getenv("BITBUCKET_BUILD_NUMBER")) > 0
+}
+
+func (p bitbucketProvider) Info() CIInfo {
+	return CIInfo{
+		Provider:    p.Name(),
+		Branch:      os.Getenv("BITBUCKET_BRANCH"),
+		Tag:         os.Getenv("BITBUCKET_TAG"),
+		PullRequest: os.Getenv("BITBUCKET_PR_ID"),
+		BuildNumber: os.Getenv("BITBUCKET_BUILD_NUMBER"),
+		Commit:      os.Getenv("BITBUCKET_COMMIT"),
+	}
+}
+
+// Export write the dotenv file, share it with the next steps as artifact
+func (p bitbucketProvider) Export(export CIExport) error {
+	return writeDotEnv(export)
+}
+
+// Jenkins
+type jenkinsProvider struct{}
+
+func (p jenkinsProvider) Name() string {
+	return "jenkins"
+}
+
+func (p jenkinsProvider) Detect() bool {
+	return len(os.Getenv("JENKINS_URL")) > 0
+}
+
+func (p jenkinsProvider) Info() CIInfo {
+	info := CIInfo{
+		Provider:    p.Name(),
+		Tag:         os.Getenv("TAG_NAME"),
+		PullRequest: os.Getenv("CHANGE_ID"),
+		BuildNumber: os.Getenv("BUILD_NUMBER"),
+		Commit:      os.Getenv("GIT_COMMIT"),
+	}
+	switch {
+	case len(info.PullRequest) > 0:
+		info.Branch = os.Getenv("CHANGE_BRANCH")
+	case len(info.Tag) > 0:
+		// tag build has no branch
+	case len(os.Getenv("BRANCH_NAME")) > 0:
+		info.Branch = os.Getenv("BRANCH_NAME")
+	default:
+		info.Branch = strings.TrimPrefix(os.Getenv("GIT_BRANCH"), "origin/")
+	}
+	return info
+}
+
+// Export write the dotenv file, use it with the readProperties step
+func (p jenkinsProvider) Export(export CIExport) error {
+	return writeDotEnv(export)
+}
+
+// Tekton
+type tektonProvider struct{}
+
+const tektonResultsDir = "/tekton/results"
+
+func (p tektonProvider) Name() string {
+	return "tekton"
+}
+
+func (p tektonProvider) Detect() bool {
+	_, err := os.Stat(tektonResultsDir)
+	return err == nil
+}
+
+// Info tekton does not provide the git information, the pipeline parameters are mapped to the environment variables
+func (p tektonProvider) Info() CIInfo {
+	info := CIInfo{Provider: p.Name(), BuildNumber: os.Getenv("TEKTON_PIPELINE_RUN"), Commit: os.Getenv("GIT_COMMIT")}
+	parseGitRef(os.Getenv("GIT_REF"), &info)
+	return info
+}
+
+// Export write the task results, the result name is the key of the value
+func (p tektonProvider) Export(export CIExport) error {
+	for _, k := range sortedKeys(export.Values) {
+		if err := WriteToFile(filepath.Join(tektonResultsDir, k), export.Values[k]); err != nil {
+			return err
+		}
+	}
+	log.Info("Export tekton results", log.F("dir", tektonResultsDir))
+	return nil
+}
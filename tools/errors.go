@@ -0,0 +1,51 @@
+package tools
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/lorislab/samo/log"
+)
+
+var (
+	// ErrNoTag the git repository has no tag or the commit is not tagged
+	ErrNoTag = errors.New("no tag found")
+	// ErrInvalidVersion the value is not valid semver 2.0 version
+	ErrInvalidVersion = errors.New("invalid semver version")
+	// ErrCommandFailed the external command failed, see CommandError for details
+	ErrCommandFailed = errors.New("command failed")
+	// ErrPrecondition the state of the project does not allow the operation
+	ErrPrecondition = errors.New("precondition failed")
+	// ErrInvalidInput invalid flag, configuration or template value
+	ErrInvalidInput = errors.New("invalid input")
+)
+
+// CommandError the external command failed
+type CommandError struct {
+	Cmd      string
+	Args     []string
+	ExitCode int
+	Stderr   string
+	Err      error
+}
+
+func (e *CommandError) Error() string {
+	msg := fmt.Sprintf("command %s failed (exit code %d)", e.Cmd, e.ExitCode)
+	if e.Err != nil {
+		msg = msg + ": " + e.Err.Error()
+	}
+	if stderr := strings.TrimSpace(e.Stderr); len(stderr) > 0 {
+		msg = msg + ": " + stderr
+	}
+	return log.Redact(msg)
+}
+
+func (e *CommandError) Unwrap() error {
+	return e.Err
+}
+
+// Is the command error is always ErrCommandFailed
+func (e *CommandError) Is(target error) bool {
+	return target == ErrCommandFailed
+}
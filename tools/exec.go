@@ -1,99 +1,48 @@
 package tools
 
 import (
-	"bufio"
-	"bytes"
+	"context"
+	"errors"
 	"os/exec"
-	"strings"
-
-	"github.com/lorislab/samo/log"
 )
 
 // ExecCmdOutput execute command with output
-func ExecCmdOutput(name string, arg ...string) string {
-	log.Debug(name, log.F("args", strings.Join(arg, " ")))
-	out, err := exec.Command(name, arg...).CombinedOutput()
-	log.Debug("Output: " + string(out))
+func ExecCmdOutput(ctx context.Context, name string, arg ...string) (string, error) {
+	out, err := Output(ctx, NewCommand(name, arg...))
 	if err != nil {
-		log.Fatal("Error execute command", log.Fields{"cmd": name, "args": arg, "output": string(out)}.E(err))
+		return "", err
 	}
-	return string(bytes.TrimRight(out, "\n"))
+	return out, nil
 }
 
-func ExecCmd(name string, arg ...string) {
-	ExecCmdAdv(nil, name, arg...)
+func ExecCmd(ctx context.Context, name string, arg ...string) error {
+	return ExecCmdAdv(ctx, nil, name, arg...)
 }
 
 // ExecCmdAdv ExecCmd execute command
-func ExecCmdAdv(exclude []int, name string, arg ...string) {
-	var args []string
-	args = append(args, arg...)
-	if len(exclude) > 0 {
-		for _, i := range exclude {
-			args[i] = "*****"
-		}
-	}
-	log.Debug(name, log.F("args", strings.Join(args, " ")))
-	cmd := exec.Command(name, arg...)
-
-	// enable always error log for the command
-	errorReader, err := cmd.StderrPipe()
-	if err != nil {
-		log.Panic("error setup error pipe", log.Fields{"name": name, "args": arg}.E(err))
-	}
-	scannerError := bufio.NewScanner(errorReader)
-	go func() {
-		for scannerError.Scan() {
-			log.Error(scannerError.Text())
-		}
-	}()
-
-	// enable info log for the command
-	if log.IsDebugLevel() {
-		// create a pipe for the output of the script
-		cmdReader, err := cmd.StdoutPipe()
-		if err != nil {
-			log.Panic("error setup output pipe", log.Fields{"name": name, "args": arg}.E(err))
-		}
-
-		scanner := bufio.NewScanner(cmdReader)
-		go func() {
-			for scanner.Scan() {
-				log.Debug(scanner.Text())
-			}
-		}()
-	}
-
-	err = cmd.Start()
-	if err != nil {
-		log.Panic("error start command", log.Fields{"name": name, "args": arg}.E(err))
-	}
-
-	err = cmd.Wait()
-	if err != nil {
-		log.Fatal("Error execute command", log.E(err))
-	}
+func ExecCmdAdv(ctx context.Context, exclude []int, name string, arg ...string) error {
+	return Run(ctx, Command{Name: name, Args: arg, Mask: exclude})
 }
 
-func execCmdErr(name string, arg ...string) error {
-	log.Debug(name, log.F("args", strings.Join(arg, " ")))
-	out, err := exec.Command(name, arg...).CombinedOutput()
-	log.Debug("Output: " + string(out))
-	if err != nil {
-		log.Fatal("Error execute command", log.Fields{"cmd": name, "args": arg, "output": string(out)}.E(err))
-	}
+func execCmdErr(ctx context.Context, name string, arg ...string) error {
+	_, err := Output(ctx, NewCommand(name, arg...))
 	return err
 }
 
-func CmdOutputErr(name string, arg ...string) (string, error) {
-	return CmdOutputErrAdv(true, name, arg...)
+func CmdOutputErr(ctx context.Context, name string, arg ...string) (string, error) {
+	return CmdOutputErrAdv(ctx, true, name, arg...)
+}
+
+func CmdOutputErrAdv(ctx context.Context, output bool, name string, arg ...string) (string, error) {
+	return Output(ctx, Command{Name: name, Args: arg, Quiet: !output})
 }
 
-func CmdOutputErrAdv(output bool, name string, arg ...string) (string, error) {
-	log.Debug(name, log.F("args", strings.Join(arg, " ")))
-	out, err := exec.Command(name, arg...).CombinedOutput()
-	if output {
-		log.Debug("Output: " + string(out))
+// commandError create command error with the exit code of the process
+func commandError(name string, args []string, stderr string, err error) error {
+	code := -1
+	var exitErr *exec.ExitError
+	if errors.As(err, &exitErr) {
+		code = exitErr.ExitCode()
 	}
-	return string(bytes.TrimRight(out, "\n")), err
+	return &CommandError{Cmd: name, Args: args, ExitCode: code, Stderr: stderr, Err: err}
 }
@@ -2,6 +2,7 @@ package tools
 
 import (
 	"bufio"
+	"fmt"
 	"os"
 	"path/filepath"
 
@@ -17,65 +18,49 @@ func Exists(file string) bool {
 }
 
 // WriteBytesToFile writes the byte array into the file
-func WriteBytesToFile(filename string, data []byte) {
+func WriteBytesToFile(filename string, data []byte) error {
 	dir := filepath.Dir(filename)
 	err := os.MkdirAll(dir, os.ModePerm)
 	if err != nil {
-		panic(err)
+		return fmt.Errorf("error create directory %s: %w", dir, err)
 	}
 
 	file, err := os.Create(filename)
 	if err != nil {
-		panic(err)
+		return fmt.Errorf("error create file %s: %w", filename, err)
 	}
+	defer file.Close()
 	w := bufio.NewWriter(file)
 
 	_, err = w.Write(data)
 	if err != nil {
-		panic(err)
+		return fmt.Errorf("error write file %s: %w", filename, err)
 	}
 	err = w.Flush()
 	if err != nil {
-		panic(err)
+		return fmt.Errorf("error write file %s: %w", filename, err)
 	}
+	return nil
 }
 
 // WriteToFile writes the data into the file
-func WriteToFile(filename, data string) {
-	dir := filepath.Dir(filename)
-	err := os.MkdirAll(dir, os.ModePerm)
-	if err != nil {
-		panic(err)
-	}
-
-	file, err := os.Create(filename)
-	if err != nil {
-		panic(err)
-	}
-	w := bufio.NewWriter(file)
-
-	_, err = w.WriteString(data)
-	if err != nil {
-		panic(err)
-	}
-	err = w.Flush()
-	if err != nil {
-		panic(err)
-	}
+func WriteToFile(filename, data string) error {
+	return WriteBytesToFile(filename, []byte(data))
 }
 
 // ReplaceTextInFile replaces test in the file at the position b and e
-func ReplaceTextInFile(filename, text string, b, e int64) {
+func ReplaceTextInFile(filename, text string, b, e int64) error {
 	buf, err := os.ReadFile(filename)
 	if err != nil {
-		log.Panic("error read test file", log.E(err).F("filename", filename))
+		return fmt.Errorf("error read file %s: %w", filename, err)
 	}
 	result := string(buf)
 	result = result[:b] + text + result[e:]
 	err = os.WriteFile(filename, []byte(result), 0666)
 	if err != nil {
-		log.Panic("error write data to file", log.E(err).F("filename", filename))
+		return fmt.Errorf("error write data to file %s: %w", filename, err)
 	}
+	return nil
 }
 
 // GetAllFilePathsInDirectory get list of all files in the directory
@@ -1,85 +1,156 @@
 package tools
 
 import (
+	"context"
+	"fmt"
 	"os"
 	"strings"
 
 	"github.com/lorislab/samo/log"
 )
 
-func GitBranch() string {
-	tmp, exists := os.LookupEnv("GITHUB_REF")
-	if exists && len(tmp) > 0 {
-		return strings.TrimPrefix(tmp, "refs/heads/")
+// GitBranch branch of the CI build or the current git branch
+func GitBranch(ctx context.Context) (string, error) {
+	if ci := DetectCI(); ci != nil {
+		info := ci.Info()
+		log.Debug("CI build", log.F("provider", info.Provider).F("branch", info.Branch).F("tag", info.Tag).F("pr", info.PullRequest))
+		if len(info.Branch) > 0 {
+			return info.Branch, nil
+		}
+	}
+	tmp, err := Output(ctx, NewCommand("git", "rev-parse", "--abbrev-ref", "HEAD"))
+	if err != nil {
+		return "", err
+	}
+	return strings.TrimPrefix(tmp, "heads/"), nil
+}
+
+// GitBranches names of the local and remote branches, the remote name is removed from the remote branches
+func GitBranches(ctx context.Context) ([]string, error) {
+	output, err := Output(ctx, NewCommand("git", "for-each-ref", "--format=%(refname)", "refs/heads", "refs/remotes"))
+	if err != nil {
+		return nil, err
 	}
-	tmp, exists = os.LookupEnv("CI_COMMIT_REF_NAME")
-	if exists && len(tmp) > 0 {
-		return tmp
+	var result []string
+	for _, ref := range strings.Split(output, "\n") {
+		switch {
+		case strings.HasPrefix(ref, "refs/heads/"):
+			result = append(result, strings.TrimPrefix(ref, "refs/heads/"))
+		case strings.HasPrefix(ref, "refs/remotes/"):
+			items := strings.SplitN(strings.TrimPrefix(ref, "refs/remotes/"), "/", 2)
+			if len(items) == 2 && items[1] != "HEAD" {
+				result = append(result, items[1])
+			}
+		}
 	}
-	tmp = ExecCmdOutput("git", "rev-parse", "--abbrev-ref", "HEAD")
-	return strings.TrimPrefix(tmp, "heads/")
+	return result, nil
 }
 
-// Git execute git command
-func Git(arg ...string) {
-	err := execCmdErr("git", arg...)
+// Git execute git command, the command is cancelled with the context
+func Git(ctx context.Context, arg ...string) error {
+	err := execCmdErr(ctx, "git", arg...)
 	if err != nil {
-		ExecCmd("rm", "-f", ".git/index.lock")
+		if e := os.Remove(".git/index.lock"); e == nil {
+			log.Debug("Remove git index lock file")
+		}
 	}
+	return err
+}
+
+// GitPush push the changes to the remote repository, the push is retried with the retry policy
+func GitPush(ctx context.Context, arg ...string) error {
+	c := NewCommand("git", append([]string{"push"}, arg...)...)
+	c.Retry = true
+	return Run(ctx, c)
+}
+
+// GitVerifyTag verify the signature of the tag. The SSH signatures are checked against the allowed signers file.
+func GitVerifyTag(ctx context.Context, tag, allowedSigners string) error {
+	var args []string
+	if len(allowedSigners) > 0 {
+		if !Exists(allowedSigners) {
+			return fmt.Errorf("%w: allowed signers file %s does not exists", ErrPrecondition, allowedSigners)
+		}
+		args = append(args, "-c", "gpg.ssh.allowedSignersFile="+allowedSigners)
+	}
+	args = append(args, "verify-tag", "--verbose", tag)
+	out, err := Output(ctx, NewCommand("git", args...))
+	if err != nil {
+		return fmt.Errorf("%w: tag %s has no valid signature: %v", ErrPrecondition, tag, err)
+	}
+	log.Debug("Tag signature", log.F("tag", tag).F("output", out))
+	return nil
 }
 
 type GitDescribe struct {
 	Tag, Count, Hash string
 }
 
-func GitDescribeInfo() GitDescribe {
-	return gitDescribe("")
+// GitDescribeInfo describe the current commit, the errors of the cancelled context are returned
+func GitDescribeInfo(ctx context.Context) (GitDescribe, error) {
+	return gitDescribe(ctx, "")
 }
 
-func gitDescribe(exclude string) GitDescribe {
+func gitDescribe(ctx context.Context, exclude string) (GitDescribe, error) {
 	args := []string{"describe", "--long", "--abbrev=100"}
 	if len(exclude) > 0 {
 		args = append(args, "--exclude", exclude)
 	}
-	output, err := CmdOutputErr("git", args...)
+	output, err := Output(ctx, NewCommand("git", args...))
 	if err == nil {
 		items := strings.Split(output, "-")
 		return GitDescribe{
 			Tag:   items[0],
 			Count: items[1],
 			Hash:  strings.TrimPrefix(items[2], "g"),
-		}
+		}, nil
+	}
+	if ctx.Err() != nil {
+		return GitDescribe{}, ctx.Err()
 	}
 
 	count := "0"
 	hash := ""
-	tmp, err := CmdOutputErr("git", "rev-list", "--max-count=1", "HEAD")
+	tmp, err := Output(ctx, NewCommand("git", "rev-list", "--max-count=1", "HEAD"))
 	if err == nil {
 		hash = tmp
-		c, err := CmdOutputErr("git", "rev-list", "--count", "HEAD")
+		c, err := Output(ctx, NewCommand("git", "rev-list", "--count", "HEAD"))
 		if err == nil {
 			count = c
 		}
 	}
+	if ctx.Err() != nil {
+		return GitDescribe{}, ctx.Err()
+	}
 	return GitDescribe{
 		Tag:   "",
 		Count: count,
 		Hash:  hash,
-	}
+	}, nil
 }
 
-func GitDescribeExclude(tag string) GitDescribe {
-	return gitDescribe(tag)
+// GitDescribeExclude describe the current commit ignoring the tag
+func GitDescribeExclude(ctx context.Context, tag string) (GitDescribe, error) {
+	return gitDescribe(ctx, tag)
 }
 
-func GitLogMessages(from, to string) []string {
-	output, err := CmdOutputErrAdv(false, "git", "--no-pager", "log", `--pretty=format:"%s"`, from+"..."+to)
+// GitLogMessages full commit messages of the revision range, empty from is the history of the revision
+func GitLogMessages(ctx context.Context, from, to string) ([]string, error) {
+	rev := from + "..." + to
+	if len(from) == 0 {
+		rev = to
+	}
+	// full messages separated by the record separator, the messages are multi-line
+	output, err := Output(ctx, Command{Name: "git", Args: []string{"--no-pager", "log", "--format=%B%x1e", rev}, Quiet: true})
 	if err != nil {
-		log.Fatal("Error execute git log messages", log.Fields{"from": from, "to": to})
+		return nil, fmt.Errorf("error execute git log messages %s...%s: %w", from, to, err)
 	}
-	log.Debug("git log result", log.F("commits", len(output)))
-	if len(output) < 1 {
-		return []string{}
+	result := []string{}
+	for _, msg := range strings.Split(output, "\x1e") {
+		if msg = strings.TrimSpace(msg); len(msg) > 0 {
+			result = append(result, msg)
+		}
 	}
-	return strings.Split(output, "\n")
+	log.Debug("git log result", log.F("commits", len(result)))
+	return result, nil
 }
@@ -0,0 +1,155 @@
+package tools
+
+import (
+	"context"
+	"errors"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/lorislab/samo/log"
+)
+
+// RetryPolicy retry of the failed network commands with exponential backoff
+type RetryPolicy struct {
+	// Attempts number of retries after the first failed attempt. Zero disable the retry.
+	Attempts int
+	// Delay before the first retry, the delay is doubled for each next retry
+	Delay time.Duration
+	// MaxDelay maximum delay between two retries
+	MaxDelay time.Duration
+}
+
+// ExecOptions options of all external commands
+type ExecOptions struct {
+	// Timeout of the command. Zero means no timeout.
+	Timeout time.Duration
+	// Retry policy of the commands with the retry flag
+	Retry RetryPolicy
+}
+
+// DefaultExecOptions options of the external commands without the options of the context, no timeout and no retry
+func DefaultExecOptions() ExecOptions {
+	return ExecOptions{Retry: RetryPolicy{Delay: 2 * time.Second, MaxDelay: 30 * time.Second}}
+}
+
+// nonRetryableErrors the stderr messages of the errors which will not be fixed by retry
+var nonRetryableErrors = []string{
+	"unauthorized",
+	"authentication required",
+	"access denied",
+	"permission denied",
+	"denied:",
+	"forbidden",
+	"not found",
+	"manifest unknown",
+	"name unknown",
+	"invalid reference format",
+	"no such file or directory",
+	"already exists",
+	"rejected",
+}
+
+// nonRetryableStatus the HTTP status 401, 403 or 404 of the response, the bare numbers of the digests, sizes or ports are ignored
+var nonRetryableStatus = regexp.MustCompile(`\b(?:status(?: code)?|http/[0-9.]+|response)\s*:?\s*(?:401|403|404)\b|\b(?:401|403|404)\s+(?:unauthorized|forbidden|not found)\b`)
+
+// IsRetryable check if the failed command could be successful in the next attempt
+func IsRetryable(err error) bool {
+	if err == nil || errors.Is(err, context.Canceled) || errors.Is(err, context.DeadlineExceeded) {
+		return false
+	}
+	var cmdErr *CommandError
+	if !errors.As(err, &cmdErr) {
+		return false
+	}
+	// command could not be started
+	if cmdErr.ExitCode < 0 {
+		return false
+	}
+	stderr := strings.ToLower(cmdErr.Stderr)
+	for _, msg := range nonRetryableErrors {
+		if strings.Contains(stderr, msg) {
+			return false
+		}
+	}
+	return !nonRetryableStatus.MatchString(stderr)
+}
+
+// Run execute the command with the runner of the context, the timeout and the retry policy
+func Run(ctx context.Context, c Command) error {
+	_, err := execute(ctx, c, func(ctx context.Context, r Runner) (string, error) {
+		return "", r.Run(ctx, c)
+	})
+	return err
+}
+
+// Output execute the command with the runner of the context, the timeout and the retry policy and returns the output
+func Output(ctx context.Context, c Command) (string, error) {
+	return execute(ctx, c, func(ctx context.Context, r Runner) (string, error) {
+		return r.Output(ctx, c)
+	})
+}
+
+func execute(ctx context.Context, c Command, fn func(ctx context.Context, r Runner) (string, error)) (string, error) {
+	e := ExecutorFrom(ctx)
+	opts, r := e.Options, e.Runner
+
+	timeout := c.Timeout
+	if timeout == 0 {
+		timeout = opts.Timeout
+	}
+	attempts := 1
+	if c.Retry && opts.Retry.Attempts > 0 {
+		attempts = attempts + opts.Retry.Attempts
+	}
+	delay := opts.Retry.Delay
+
+	for attempt := 1; ; attempt++ {
+		out, err := executeTimeout(ctx, timeout, r, fn)
+		if err == nil || attempt >= attempts {
+			return out, err
+		}
+		// the command timeout could be retried, the cancelled context not
+		var timeoutErr *timeoutError
+		if !IsRetryable(err) && !(errors.As(err, &timeoutErr) && ctx.Err() == nil) {
+			return out, err
+		}
+		log.Warn("Command failed, retry", log.F("cmd", c.String()).F("attempt", attempt).F("attempts", attempts).F("delay", delay.String()).E(err))
+		select {
+		case <-ctx.Done():
+			return out, ctx.Err()
+		case <-time.After(delay):
+		}
+		delay = delay * 2
+		if opts.Retry.MaxDelay > 0 && delay > opts.Retry.MaxDelay {
+			delay = opts.Retry.MaxDelay
+		}
+	}
+}
+
+func executeTimeout(ctx context.Context, timeout time.Duration, r Runner, fn func(ctx context.Context, r Runner) (string, error)) (string, error) {
+	if timeout <= 0 {
+		return fn(ctx, r)
+	}
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+	out, err := fn(ctx, r)
+	if err != nil && errors.Is(ctx.Err(), context.DeadlineExceeded) {
+		return out, &timeoutError{timeout: timeout, err: err}
+	}
+	return out, err
+}
+
+// timeoutError the command was killed after the timeout
+type timeoutError struct {
+	timeout time.Duration
+	err     error
+}
+
+func (e *timeoutError) Error() string {
+	return "command timeout after " + e.timeout.String() + ": " + e.err.Error()
+}
+
+func (e *timeoutError) Unwrap() []error {
+	return []error{e.err, context.DeadlineExceeded}
+}
@@ -0,0 +1,65 @@
+package tools
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+)
+
+func TestIsRetryable(t *testing.T) {
+	tests := []struct {
+		stderr    string
+		retryable bool
+	}{
+		{stderr: "net/http: TLS handshake timeout", retryable: true},
+		{stderr: "error pulling sha256:9a404bc1d2404e3f, connection reset by peer", retryable: true},
+		{stderr: "received 4041 bytes, unexpected EOF", retryable: true},
+		{stderr: "dial tcp 10.0.0.1:4040: i/o timeout", retryable: true},
+		{stderr: "unexpected status code 404", retryable: false},
+		{stderr: "Error: failed to fetch https://example.com/index.yaml : 403 Forbidden", retryable: false},
+		{stderr: "HTTP/1.1 401", retryable: false},
+		{stderr: "error: manifest unknown", retryable: false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.stderr, func(t *testing.T) {
+			err := &CommandError{Cmd: "docker", ExitCode: 1, Stderr: tt.stderr, Err: errors.New("exit status 1")}
+			if retryable := IsRetryable(err); retryable != tt.retryable {
+				t.Errorf("retryable %v, expected %v", retryable, tt.retryable)
+			}
+		})
+	}
+}
+
+func TestIsRetryableContext(t *testing.T) {
+	for _, err := range []error{nil, context.Canceled, fmt.Errorf("wrap: %w", context.DeadlineExceeded), errors.New("no command")} {
+		if IsRetryable(err) {
+			t.Errorf("error %v must not be retryable", err)
+		}
+	}
+}
+
+func TestRunRetryOptions(t *testing.T) {
+	fake := NewFakeRunner().OnTimes("docker push", "connection reset by peer", errors.New("exit status 1"), 2)
+	ctx := WithExecOptions(WithRunner(context.Background(), fake), ExecOptions{Retry: RetryPolicy{Attempts: 2, Delay: time.Millisecond}})
+
+	c := NewCommand("docker", "push", "samo:1.0.0")
+	c.Retry = true
+	if err := Run(ctx, c); err != nil {
+		t.Fatalf("run with the retry: %v", err)
+	}
+	if calls := len(fake.Calls()); calls != 3 {
+		t.Errorf("calls %d, expected 3", calls)
+	}
+
+	// the options of the other context are not changed
+	fake.Reset()
+	other := NewFakeRunner().On("docker push", "connection reset by peer", errors.New("exit status 1"))
+	if err := Run(WithRunner(context.Background(), other), c); err == nil {
+		t.Fatal("run without the retry options must fail")
+	}
+	if calls := len(other.Calls()); calls != 1 {
+		t.Errorf("calls %d without the retry options, expected 1", calls)
+	}
+}
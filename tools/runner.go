@@ -0,0 +1,201 @@
+package tools
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"io"
+	"os"
+	"os/exec"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/lorislab/samo/log"
+)
+
+// Command external command
+type Command struct {
+	Name string
+	Args []string
+	// Mask indexes of the arguments which are not logged
+	Mask []int
+	// Env additional environment variables in the form key=value
+	Env []string
+	// Dir working directory of the command. Default current directory.
+	Dir string
+	// Stdin input of the command
+	Stdin io.Reader
+	// Stdout output of the command. Default the output is logged in the debug level.
+	Stdout io.Writer
+	// Quiet do not log the output of the command
+	Quiet bool
+	// Retry the failed command with the retry policy, use it for the network operations
+	Retry bool
+	// Timeout of the command. Default timeout of the exec options.
+	Timeout time.Duration
+}
+
+// NewCommand create command
+func NewCommand(name string, args ...string) Command {
+	return Command{Name: name, Args: args}
+}
+
+func (c Command) MaskedArgs() []string {
+	args := append([]string{}, c.Args...)
+	for _, i := range c.Mask {
+		if i >= 0 && i < len(args) {
+			args[i] = log.Redacted
+		}
+	}
+	for i := range args {
+		args[i] = log.Redact(args[i])
+	}
+	return args
+}
+
+// String command line with the masked arguments
+func (c Command) String() string {
+	return strings.TrimSpace(c.Name + " " + strings.Join(c.MaskedArgs(), " "))
+}
+
+// Runner execute the external commands
+type Runner interface {
+	// Run execute the command, the output of the command is logged
+	Run(ctx context.Context, cmd Command) error
+	// Output execute the command and returns the combined output without the trailing new lines
+	Output(ctx context.Context, cmd Command) (string, error)
+}
+
+// Executor runner and options of the external commands, the executor is injected with the context
+type Executor struct {
+	Runner  Runner
+	Options ExecOptions
+}
+
+type executorKey struct{}
+
+// WithExecutor context with the runner and the options of the external commands
+func WithExecutor(ctx context.Context, e Executor) context.Context {
+	return context.WithValue(ctx, executorKey{}, e)
+}
+
+// ExecutorFrom executor of the context, default the runner of the os processes with the default options
+func ExecutorFrom(ctx context.Context) Executor {
+	if e, ok := ctx.Value(executorKey{}).(Executor); ok && e.Runner != nil {
+		return e
+	}
+	return Executor{Runner: ExecRunner{}, Options: DefaultExecOptions()}
+}
+
+// WithRunner context with the runner of the external commands, the options of the context are kept
+func WithRunner(ctx context.Context, r Runner) context.Context {
+	e := ExecutorFrom(ctx)
+	e.Runner = r
+	return WithExecutor(ctx, e)
+}
+
+// RunnerFrom runner of the context, default the runner of the os processes
+func RunnerFrom(ctx context.Context) Runner {
+	return ExecutorFrom(ctx).Runner
+}
+
+// WithExecOptions context with the timeout and the retry policy of the external commands, the runner of the context is kept
+func WithExecOptions(ctx context.Context, opts ExecOptions) context.Context {
+	e := ExecutorFrom(ctx)
+	e.Options = opts
+	return WithExecutor(ctx, e)
+}
+
+// processWaitDelay time to wait for the interrupted process before it is killed
+const processWaitDelay = 10 * time.Second
+
+// ExecRunner runner of the os processes
+type ExecRunner struct{}
+
+func (r ExecRunner) command(ctx context.Context, c Command) *exec.Cmd {
+	cmd := exec.CommandContext(ctx, c.Name, c.Args...)
+	// send interrupt to the process on cancel, kill it if it does not stop
+	cmd.Cancel = func() error {
+		if err := cmd.Process.Signal(os.Interrupt); err != nil {
+			return cmd.Process.Kill()
+		}
+		return nil
+	}
+	cmd.WaitDelay = processWaitDelay
+	cmd.Dir = c.Dir
+	cmd.Stdin = c.Stdin
+	if len(c.Env) > 0 {
+		cmd.Env = append(os.Environ(), c.Env...)
+	}
+	return cmd
+}
+
+func (r ExecRunner) Run(ctx context.Context, c Command) error {
+	args := c.MaskedArgs()
+	log.Debug(c.Name, log.F("args", strings.Join(args, " ")))
+	cmd := r.command(ctx, c)
+
+	// enable always error log for the command
+	errorReader, err := cmd.StderrPipe()
+	if err != nil {
+		return commandError(c.Name, args, "", err)
+	}
+	var stderr bytes.Buffer
+	var wg sync.WaitGroup
+	scannerError := bufio.NewScanner(errorReader)
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		for scannerError.Scan() {
+			log.Error(scannerError.Text(), log.F("cmd", c.Name))
+			stderr.WriteString(scannerError.Text() + "\n")
+		}
+	}()
+
+	// enable info log for the command
+	if c.Stdout != nil {
+		cmd.Stdout = c.Stdout
+	} else if log.IsDebugLevel() && !c.Quiet {
+		// create a pipe for the output of the script
+		cmdReader, err := cmd.StdoutPipe()
+		if err != nil {
+			return commandError(c.Name, args, "", err)
+		}
+
+		scanner := bufio.NewScanner(cmdReader)
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for scanner.Scan() {
+				log.Debug(scanner.Text(), log.F("cmd", c.Name))
+			}
+		}()
+	}
+
+	err = cmd.Start()
+	if err != nil {
+		return commandError(c.Name, args, "", err)
+	}
+
+	wg.Wait()
+	err = cmd.Wait()
+	if err != nil {
+		return commandError(c.Name, args, stderr.String(), err)
+	}
+	return nil
+}
+
+func (r ExecRunner) Output(ctx context.Context, c Command) (string, error) {
+	args := c.MaskedArgs()
+	log.Debug(c.Name, log.F("args", strings.Join(args, " ")))
+	out, err := r.command(ctx, c).CombinedOutput()
+	if !c.Quiet {
+		log.Debug("Output: "+string(out), log.F("cmd", c.Name))
+	}
+	result := string(bytes.TrimRight(out, "\n"))
+	if err != nil {
+		return result, commandError(c.Name, args, result, err)
+	}
+	return result, nil
+}
@@ -0,0 +1,105 @@
+package tools
+
+import (
+	"context"
+	"io"
+	"strings"
+	"sync"
+)
+
+// FakeRunner runner which records the commands and returns the scripted outputs.
+// The first scripted response which prefix matches the command line is used,
+// commands without response succeed with empty output.
+type FakeRunner struct {
+	mu        sync.Mutex
+	calls     []Command
+	responses []fakeResponse
+}
+
+type fakeResponse struct {
+	prefix string
+	output string
+	err    error
+	times  int
+}
+
+// NewFakeRunner create fake runner
+func NewFakeRunner() *FakeRunner {
+	return &FakeRunner{}
+}
+
+// On script the output and error of the commands with the command line prefix
+func (f *FakeRunner) On(prefix, output string, err error) *FakeRunner {
+	return f.OnTimes(prefix, output, err, 0)
+}
+
+// OnTimes script the output and error of the commands with the command line prefix for the number of calls.
+// Zero times means for all calls.
+func (f *FakeRunner) OnTimes(prefix, output string, err error, times int) *FakeRunner {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.responses = append(f.responses, fakeResponse{prefix: prefix, output: output, err: err, times: times})
+	return f
+}
+
+// Calls recorded commands
+func (f *FakeRunner) Calls() []Command {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return append([]Command{}, f.calls...)
+}
+
+// Lines recorded command lines with the masked arguments, use it for the golden files
+func (f *FakeRunner) Lines() []string {
+	var result []string
+	for _, c := range f.Calls() {
+		result = append(result, c.String())
+	}
+	return result
+}
+
+// Reset remove the recorded commands
+func (f *FakeRunner) Reset() {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.calls = nil
+}
+
+func (f *FakeRunner) Run(ctx context.Context, cmd Command) error {
+	_, err := f.Output(ctx, cmd)
+	return err
+}
+
+func (f *FakeRunner) Output(ctx context.Context, cmd Command) (string, error) {
+	if err := ctx.Err(); err != nil {
+		return "", err
+	}
+	if cmd.Stdin != nil {
+		// keep the input for the assertions, the reader is consumed
+		data, _ := io.ReadAll(cmd.Stdin)
+		cmd.Stdin = strings.NewReader(string(data))
+	}
+
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.calls = append(f.calls, cmd)
+
+	line := strings.TrimSpace(cmd.Name + " " + strings.Join(cmd.Args, " "))
+	for i := range f.responses {
+		r := &f.responses[i]
+		if r.times < 0 || !strings.HasPrefix(line, r.prefix) {
+			continue
+		}
+		if r.times > 0 {
+			r.times--
+			if r.times == 0 {
+				r.times = -1
+			}
+		}
+		if r.err != nil {
+			return r.output, &CommandError{Cmd: cmd.Name, Args: cmd.MaskedArgs(), ExitCode: 1, Stderr: r.output, Err: r.err}
+		}
+		return r.output, nil
+	}
+	return "", nil
+}
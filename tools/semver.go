@@ -1,15 +1,16 @@
 package tools
 
 import (
+	"fmt"
+
 	"github.com/Masterminds/semver/v3"
-	"github.com/lorislab/samo/log"
 )
 
 // CreateSemVer create SemVer version for the project
-func CreateSemVer(version string) *semver.Version {
+func CreateSemVer(version string) (*semver.Version, error) {
 	result, e := semver.NewVersion(version)
 	if e != nil {
-		log.Fatal("Version value is not valid semver 2.0.", log.F("version", version))
+		return nil, fmt.Errorf("%w: version value '%s' is not valid semver 2.0: %v", ErrInvalidVersion, version, e)
 	}
-	return result
+	return result, nil
 }
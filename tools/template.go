@@ -2,12 +2,11 @@ package tools
 
 import (
 	"bytes"
+	"fmt"
 	"text/template"
-
-	"github.com/lorislab/samo/log"
 )
 
-func Template(obj interface{}, data string) string {
+func Template(obj interface{}, data string) (string, error) {
 	temp := template.New("template")
 
 	f := map[string]interface{}{
@@ -16,15 +15,15 @@ func Template(obj interface{}, data string) string {
 
 	t, err := temp.Funcs(f).Parse(data)
 	if err != nil {
-		log.Panic("error parse template data", log.E(err))
+		return "", fmt.Errorf("%w: error parse template data: %v", ErrInvalidInput, err)
 	}
 
 	var tpl bytes.Buffer
 	err = t.Execute(&tpl, obj)
 	if err != nil {
-		log.Panic("error execute template", log.E(err))
+		return "", fmt.Errorf("%w: error execute template: %v", ErrInvalidInput, err)
 	}
-	return tpl.String()
+	return tpl.String(), nil
 }
 
 func trunc(c int, s string) string {